@@ -0,0 +1,266 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+
+	"github.com/swartzrock/ecsview/cmd/aws"
+	"github.com/swartzrock/ecsview/cmd/ecsview"
+	"github.com/swartzrock/ecsview/cmd/pages"
+	"github.com/swartzrock/ecsview/cmd/ui"
+)
+
+const actionPopupName = "action"
+
+// The view that had focus before the action's popups were shown, which gets it back when they close
+var actionPreviousFocus tview.Primitive
+
+// An action the user can take on a cluster details page, eg scaling the selected service
+type pageAction struct {
+	key  rune
+	name string
+
+	// Whether the action acts on every marked row, so the user can mark the page's rows with Space
+	marks bool
+
+	run func(page *pages.ClusterDetailsPage, cluster *aws.EcsCluster, ecsData *ecsview.ClusterData)
+}
+
+// The actions of each cluster details page, keyed by the page name
+var pageActions = make(map[string][]pageAction)
+
+// Returns the front cluster details page if its table has focus, or nil if the cluster table (or nothing) does, since
+// the page's actions act on its selected row
+func getFocusedClusterDetailsPage() *pages.ClusterDetailsPage {
+	page := getFrontClusterDetailsPage()
+	if page == nil || !page.GetTable().HasFocus() {
+		return nil
+	}
+	return page
+}
+
+// Runs the focused cluster details page's action for the key, returning false if the page has no action for it
+func runPageAction(key rune) bool {
+	page := getFocusedClusterDetailsPage()
+	if page == nil {
+		return false
+	}
+	for _, action := range pageActions[page.Name] {
+		if action.key != key {
+			continue
+		}
+
+		// Act on the data the user is looking at, so do nothing until the cluster loads
+		cluster := getCurrentlySelectedCluster()
+		if cluster == nil {
+			return true
+		}
+		if ecsData, _ := ecsview.GetCachedClusterData(cluster); ecsData != nil {
+			action.run(page, cluster, ecsData)
+		}
+		return true
+	}
+	return false
+}
+
+// Marks or unmarks the selected row of the focused cluster details page, returning false if none of its actions act on
+// marked rows
+func toggleSelectedRowMark() bool {
+	page := getFocusedClusterDetailsPage()
+	if page == nil || !pageMarksRows(page) {
+		return false
+	}
+	page.Rows.ToggleMark()
+	return true
+}
+
+// Returns true if any of the page's actions act on its marked rows
+func pageMarksRows(page *pages.ClusterDetailsPage) bool {
+	for _, action := range pageActions[page.Name] {
+		if action.marks {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the ids referenced by the page's marked rows or, if none are marked, by its selected row
+func getMarkedRowIds(page *pages.ClusterDetailsPage) []string {
+	if marked := page.Rows.Marked(); len(marked) > 0 {
+		return marked
+	}
+	if id, ok := getSelectedRowId(page); ok {
+		return []string{id}
+	}
+	return nil
+}
+
+// Returns the id referenced by the page's selected row, eg a service arn, or false if no row is selected
+func getSelectedRowId(page *pages.ClusterDetailsPage) (string, bool) {
+	row, _ := page.GetTable().GetSelection()
+	if row < 1 || row >= page.GetTable().GetRowCount() {
+		return "", false
+	}
+	id, ok := page.GetTable().GetCell(row, 0).GetReference().(string)
+	return id, ok
+}
+
+// Describes the front cluster details page's actions for the footer
+func pageActionsFooterText() string {
+	page := getFrontClusterDetailsPage()
+	if page == nil {
+		return ""
+	}
+	commands := make([]string, 0)
+	if pageMarksRows(page) {
+		commands = append(commands, `[white::b]Space[darkcyan::-] Mark`)
+	}
+	for _, action := range pageActions[page.Name] {
+		commands = append(commands, fmt.Sprintf(`[white::b]%c[darkcyan::-] %s`, action.key, action.name))
+	}
+	return strings.Join(commands, " ")
+}
+
+// Show a popup with the form's fields and buttons, closing it if the user presses Esc
+func showActionForm(title string, form *tview.Form, width int, height int) {
+	form.SetCancelFunc(closeActionPopup)
+	form.
+		SetBorder(true).
+		SetTitle(title).
+		SetBorderColor(tcell.ColorGoldenrod)
+	showActionPopup(ui.Centered(form, width, height), true)
+}
+
+// Show a modal dialog asking the user to confirm an action, calling confirm if they do
+func showConfirmModal(text string, button string, confirm func()) {
+	modal := tview.NewModal().
+		SetText(text).
+		AddButtons([]string{button, "Cancel"}).
+		SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+			closeActionPopup()
+			if buttonLabel == button {
+				confirm()
+			}
+		})
+	showActionPopup(modal, false)
+}
+
+// Show a popup asking the user to type the expected text, eg a service's name, to confirm an action that's hard to undo,
+// calling confirm once they have
+func showTypedConfirm(title string, text string, expected string, button string, confirm func()) {
+	message := tview.NewTextView().SetText(text).SetWordWrap(true)
+	message.SetBorderPadding(1, 0, 2, 2)
+
+	input := tview.NewInputField().
+		SetLabel(fmt.Sprintf("Type %s to confirm ", expected)).
+		SetFieldWidth(len(expected) + 2)
+	submit := func() {
+		if input.GetText() == expected {
+			closeActionForm(confirm)
+		}
+	}
+	input.SetDoneFunc(func(key tcell.Key) {
+		if key == tcell.KeyEnter {
+			submit()
+		}
+	})
+
+	form := tview.NewForm().
+		AddFormItem(input).
+		AddButton(button, submit).
+		AddButton("Cancel", closeActionPopup).
+		SetCancelFunc(closeActionPopup)
+
+	layout := tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(message, 0, 1, false).
+		AddItem(form, 5, 0, true)
+	layout.
+		SetBorder(true).
+		SetTitle(title).
+		SetBorderColor(tcell.ColorGoldenrod)
+	showActionPopup(ui.Centered(layout, 70, 12), true)
+}
+
+// Show a modal dialog with the outcome of an action
+func showResultModal(text string) {
+	modal := tview.NewModal().
+		SetText(text).
+		AddButtons([]string{"OK"}).
+		SetDoneFunc(func(buttonIndex int, buttonLabel string) { closeActionPopup() })
+	showActionPopup(modal, false)
+}
+
+// Show the action's form or dialog over the main view, replacing any it was already showing
+func showActionPopup(popup tview.Primitive, resize bool) {
+	if !rootPages.HasPage(actionPopupName) {
+		actionPreviousFocus = tviewApp.GetFocus()
+	}
+	rootPages.AddPage(actionPopupName, popup, resize, true)
+	tviewApp.SetFocus(popup)
+}
+
+// Show a modal dialog describing why an action failed, offering to retry it
+func showActionErrorModal(what string, err error, retry func()) {
+	modal := tview.NewModal().
+		SetText(fmt.Sprintf("%s failed:\n\n%s", what, err)).
+		AddButtons([]string{"Retry", "Dismiss"}).
+		SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+			closeActionPopup()
+			if buttonLabel == "Retry" {
+				retry()
+			}
+		})
+	showActionPopup(modal, false)
+}
+
+// Close the action's form or dialog, returning the focus to where it was before
+func closeActionPopup() {
+	if !rootPages.HasPage(actionPopupName) {
+		return
+	}
+	rootPages.RemovePage(actionPopupName)
+	if actionPreviousFocus != nil {
+		tviewApp.SetFocus(actionPreviousFocus)
+		actionPreviousFocus = nil
+	}
+}
+
+// Close the action's form, then run next, eg to confirm what the user entered. The form moves its focus after its
+// field's handlers return, so it's closed after that rather than leave the focus on a form that's gone.
+func closeActionForm(next func()) {
+	go tviewApp.QueueUpdateDraw(func() {
+		closeActionPopup()
+		next()
+	})
+}
+
+// Run the action on a background goroutine while a dialog shows what it's doing, eg "Scaling web", then run done on
+// the UI goroutine. If the action fails, the error is shown with the option to retry it.
+// Actions aren't cancelled when the user moves to another cluster, so they're never left half done.
+func runAction(what string, action func(ctx context.Context) error, done func()) {
+	showActionPopup(tview.NewModal().SetText(fmt.Sprintf("%s…", what)), false)
+
+	go func() {
+		err := action(context.Background())
+		tviewApp.QueueUpdateDraw(func() {
+			closeActionPopup()
+			if err != nil {
+				showActionErrorModal(what, err, func() { runAction(what, action, done) })
+				return
+			}
+			done()
+		})
+	}()
+}
+
+// Show the cluster's refreshed data if it's still selected
+func renderActionResult(cluster *aws.EcsCluster) {
+	if getCurrentlySelectedCluster() == cluster {
+		renderCurrentClusterDetailsPage()
+	}
+}
@@ -1,7 +1,9 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"log"
 	"sort"
 	"strings"
 	"time"
@@ -13,27 +15,171 @@ import (
 	"github.com/thoas/go-funk"
 
 	"github.com/swartzrock/ecsview/cmd/aws"
+	"github.com/swartzrock/ecsview/cmd/config"
 	"github.com/swartzrock/ecsview/cmd/pages"
 	"github.com/swartzrock/ecsview/cmd/ui"
 	"github.com/swartzrock/ecsview/cmd/utils"
 )
 
+var appOptions Options
+var userConfig *config.Config
+var workPool *aws.WorkPool
+var activeProfiles []string
+var activeRegions []string
 var tviewApp *tview.Application
+var rootPages *tview.Pages
 var clusterTable *tview.Table
+var clusterTableRows *ui.TableRows
 var clusterDetailsPages *tview.Pages
 var clusterDetailsPageMap = make(map[int32]*pages.ClusterDetailsPage)
 var commandFooterBar *tview.TextView
 var progressFooterBar *tview.TextView
 
+const mainPageName = "main"
+const errorModalName = "error"
+
+// Command-line options for the ecsview application
+type Options struct {
+	// A JSON file of canned ECS data to display instead of querying AWS
+	FixturesFile string
+
+	// Overrides for the AWS session, eg a custom endpoint url
+	Session aws.SessionConfig
+
+	// The most AWS requests to run at once when loading many clusters or task definitions
+	Concurrency int
+
+	// How long loaded cluster data is shown before it's reloaded, or forever if zero
+	CacheTTL time.Duration
+
+	// How often to reload the selected cluster in the background, or never if zero
+	RefreshInterval time.Duration
+
+	// The regions to view clusters in, or "all" for every ECS region. Defaults to the shared config's region.
+	Regions []string
+
+	// The shared config profiles to view clusters with. Defaults to the AWS_PROFILE or default profile.
+	Profiles []string
+
+	// The YAML config file, eg with the columns to show on each page. Defaults to ~/.config/ecsview/config.yaml.
+	ConfigFile string
+
+	// The format a command prints its output in, eg "json"
+	Output string
+
+	// How often the watch and serve commands reload the clusters
+	Interval time.Duration
+
+	// The address the serve command serves metrics on, eg ":9102"
+	MetricsAddr string
+}
+
 // Entrypoint for the ecsview application
-func Entrypoint() {
-	fmt.Println("Loading information about your AWS ECS clusters and container instances...")
+func Entrypoint(options Options) {
+	appOptions = options
+	cfg, err := loadConfig(options.ConfigFile)
+	if err != nil {
+		log.Fatal("Unable to read the config file. Error: ", err)
+	}
+	userConfig = cfg
+
+	workPool = aws.NewWorkPool(options.Concurrency)
+	activeProfiles = options.Profiles
+	activeRegions = aws.ExpandRegions(options.Regions)
+	backends, err := newBackends(options, workPool, activeProfiles, activeRegions)
+	if err != nil {
+		log.Fatal("Unable to initialize the ECS backend. Error: ", err)
+	}
+	ecsview.SetBackends(backends)
+	ecsview.SetAgentVersionCheck(checksAgentVersion(options))
+	ecsview.SetWorkPool(workPool)
+	ecsview.SetCacheTTL(options.CacheTTL)
+
 	buildUIElements()
+	go runFooterClock()
+	if options.RefreshInterval > 0 {
+		go runAutoRefresh(options.RefreshInterval)
+	}
 	if err := tviewApp.Run(); err != nil {
 		panic(err)
 	}
 }
 
+// Read and check the given config file or, if none is given, the user's config file if they have one
+func loadConfig(configFile string) (*config.Config, error) {
+	path := configFile
+	if path == "" {
+		path = config.DefaultPath()
+	}
+	cfg, err := config.Load(path, configFile != "")
+	if err != nil {
+		return nil, err
+	}
+	return cfg, pages.CheckConfig(cfg)
+}
+
+// Build a backend for each profile in each region, using the default profile or region if none are given.
+// The backends serve fixtures if a fixtures file was given, otherwise they use the AWS SDK, with a session per backend.
+// Fixtures don't have profiles, so the profiles are ignored. MFA tokens are prompted for in a popup unless the session
+// options have a token provider.
+func newBackends(options Options, pool *aws.WorkPool, profiles []string, regions []string) ([]aws.Backend, error) {
+	if options.FixturesFile != "" {
+		fixtures, err := aws.LoadFixtureBackend(options.FixturesFile)
+		if err != nil {
+			return nil, err
+		}
+		if len(regions) == 0 {
+			return []aws.Backend{fixtures}, nil
+		}
+		backends := make([]aws.Backend, 0, len(regions))
+		for _, region := range regions {
+			backends = append(backends, fixtures.InRegion(region))
+		}
+		return backends, nil
+	}
+
+	if len(profiles) == 0 {
+		profiles = []string{""}
+	}
+	if len(regions) == 0 {
+		regions = []string{""}
+	}
+	backends := make([]aws.Backend, 0, len(profiles)*len(regions))
+	for _, profile := range profiles {
+		for _, region := range regions {
+			config := options.Session
+			config.Profile = profile
+			config.Region = region
+			if config.TokenProvider == nil {
+				config.TokenProvider = newMfaTokenPrompter(describeCredentials(config))
+			}
+			backend, err := aws.NewSdkBackend(config, pool)
+			if err != nil {
+				return nil, fmt.Errorf("unable to create a session for profile %q: %w", profile, err)
+			}
+			backends = append(backends, backend)
+		}
+	}
+	return backends, nil
+}
+
+// Returns true if the latest ECS Agent version should be read from Github, which is skipped when ECS is local or faked
+func checksAgentVersion(options Options) bool {
+	return options.FixturesFile == "" && options.Session.GetEndpointURL() == ""
+}
+
+// Describe the profile and role a session reads with, for the MFA prompt
+func describeCredentials(config aws.SessionConfig) string {
+	what := config.Profile
+	if what == "" {
+		what = "default profile"
+	}
+	if config.RoleArn != "" {
+		what = fmt.Sprintf("%s as %s", what, utils.RemoveAllRegex(`.*/`, config.RoleArn))
+	}
+	return what
+}
+
 // Select a cluster details page with a single key shortcut
 func selectClusterDetailsPageByKey(key int32) bool {
 	if page, found := clusterDetailsPageMap[key]; found {
@@ -52,11 +198,30 @@ func showPage(selectedPage *pages.ClusterDetailsPage, key int32) {
 	if cluster == nil {
 		return
 	}
-	ecsData := ecsview.GetClusterData(cluster)
-	commandFooterBar.Highlight(string(key)).ScrollToHighlight()
-	selectedPage.Render(ecsData)
 	clusterDetailsPages.SwitchToPage(selectedPage.Name)
-	showRefreshTime(*ecsData.Cluster.ClusterName, ecsData.Refreshed)
+	commandFooterBar.Highlight(string(key))
+	updateCommandFooterBar()
+	commandFooterBar.ScrollToHighlight()
+
+	// Stop loading a cluster the user has moved away from
+	cancelLoadOfOtherCluster(cluster)
+
+	// Show the cached data, even if it's stale, or an empty page until the cluster loads
+	ecsData, fresh := ecsview.GetCachedClusterData(cluster)
+	if ecsData != nil {
+		selectedPage.Render(ecsData)
+		renderDetails(ecsData)
+		if currentLoad == nil {
+			showClusterStatus(ecsData)
+		}
+	} else {
+		selectedPage.Render(&ecsview.ClusterData{Cluster: cluster})
+	}
+	selectedPage.Rows.Update()
+
+	if !fresh && (currentLoad == nil || currentLoad.cluster != cluster) {
+		loadCluster(cluster, false)
+	}
 
 	// If the page about to be hidden has focus, switch focus to the new page
 	if frontPageView != nil && frontPageView.HasFocus() {
@@ -73,6 +238,17 @@ func renderCurrentClusterDetailsPage() {
 	}
 }
 
+// Returns the cluster details page being shown, or nil if none is
+func getFrontClusterDetailsPage() *pages.ClusterDetailsPage {
+	name, _ := clusterDetailsPages.GetFrontPage()
+	for _, page := range clusterDetailsPageMap {
+		if page.Name == name {
+			return page
+		}
+	}
+	return nil
+}
+
 // Change focus between the cluster table and the cluster details page
 func changeFocus() {
 	_, pageView := clusterDetailsPages.GetFrontPage()
@@ -87,9 +263,61 @@ func changeFocus() {
 	}
 }
 
+// Reload the currently selected cluster from AWS and show it in the current cluster details page
+func refreshCurrentCluster() {
+	cluster := getCurrentlySelectedCluster()
+	if cluster != nil {
+		loadCluster(cluster, true)
+	}
+}
+
+// Load the cluster's data in the background, then show it if the cluster is still selected.
+// If refresh is false and the cluster was already loaded, the cached data is used.
+func loadCluster(cluster *aws.EcsCluster, refresh bool) {
+	var err error
+	startLoad(*cluster.ClusterName, cluster, func(ctx context.Context, progress ecsview.ProgressFunc) {
+		if refresh {
+			_, err = ecsview.RefreshClusterData(ctx, cluster, progress)
+		} else {
+			_, err = ecsview.GetClusterData(ctx, cluster, progress)
+		}
+	}, func() {
+		if getCurrentlySelectedCluster() == cluster {
+			renderCurrentClusterDetailsPage()
+		}
+		if err != nil {
+			showErrorModal(err, func() { loadCluster(cluster, true) })
+		}
+	})
+}
+
+// Show a modal dialog describing the error, offering to retry the action that failed
+func showErrorModal(err error, retry func()) {
+	modal := tview.NewModal().
+		SetText(fmt.Sprintf("An issue occurred calling the AWS SDK. Please confirm you have the right AWS credentials in place.\n\n%s", err)).
+		AddButtons([]string{"Retry", "Dismiss"}).
+		SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+			rootPages.RemovePage(errorModalName)
+			if buttonLabel == "Retry" {
+				retry()
+			}
+		})
+	rootPages.AddPage(errorModalName, modal, false, true)
+}
+
+// Returns true if a modal dialog is displayed over the main view
+func isModalShowing() bool {
+	return rootPages.GetPageCount() > 1
+}
+
 // Handle a user input event
 func handleAppInput(event *tcell.EventKey) *tcell.EventKey {
 
+	// Modal dialogs receive keys unmodified
+	if isModalShowing() {
+		return event
+	}
+
 	if event.Key() == tcell.KeyTab {
 		changeFocus()
 	}
@@ -101,11 +329,45 @@ func handleAppInput(event *tcell.EventKey) *tcell.EventKey {
 			return event
 		}
 
+		// g and G move to the first and last table rows, so the region selector uses e
+		if key == 'e' || key == 'E' {
+			showRegionSelector()
+			return nil
+		}
+
+		if key == 'p' || key == 'P' {
+			showProfileSelector()
+			return nil
+		}
+
+		if key == '/' {
+			showFilterInput()
+			return nil
+		}
+
+		if key == ' ' && toggleSelectedRowMark() {
+			return nil
+		}
+
+		if runPageAction(key) {
+			return nil
+		}
+
+		if page := getFrontClusterDetailsPage(); page != nil && key == 's' {
+			page.Rows.SortByNextColumn()
+			return nil
+		}
+
+		if page := getFrontClusterDetailsPage(); page != nil && key == 'S' {
+			page.Rows.ReverseSort()
+			return nil
+		}
+
 		if key == 'r' || key == 'R' {
-			cluster := getCurrentlySelectedCluster()
-			if cluster != nil {
-				ecsview.RefreshClusterData(getCurrentlySelectedCluster())
-				renderCurrentClusterDetailsPage()
+			if clusterTableRows.Total() == 0 {
+				reloadClusters()
+			} else {
+				refreshCurrentCluster()
 			}
 		}
 	}
@@ -132,7 +394,25 @@ func getCurrentlySelectedCluster() *aws.EcsCluster {
 
 func showRefreshTime(what string, when time.Time) {
 	progressFooterBar.Clear()
-	fmt.Fprintf(progressFooterBar, "%s refreshed at %s", what, utils.FormatLocalTimeAmPmSecs(when))
+	fmt.Fprintf(progressFooterBar, "%s refreshed at %s (%s ago)", what, utils.FormatLocalTimeAmPmSecs(when), utils.FormatAge(time.Since(when)))
+}
+
+// Show the cluster's refresh time and data age in the footer, or a warning if some of its data failed to load.
+// The footer clock keeps the age up to date.
+func showClusterStatus(ecsData *ecsview.ClusterData) {
+	footerClusterData = ecsData
+	if ecsData.Err == nil {
+		showRefreshTime(*ecsData.Cluster.ClusterName, ecsData.Refreshed)
+		return
+	}
+	showLoadError(fmt.Sprintf("%s partially loaded at %s (%s ago)", *ecsData.Cluster.ClusterName,
+		utils.FormatLocalTimeAmPmSecs(ecsData.Refreshed), utils.FormatAge(time.Since(ecsData.Refreshed))))
+}
+
+// Show a load failure in the footer with a reminder of how to retry
+func showLoadError(what string) {
+	progressFooterBar.Clear()
+	fmt.Fprintf(progressFooterBar, "[red::b]⚠️ %s[-::-], [white::b]R[-::-] to retry", what)
 }
 
 // Build the UI elements and configures the application
@@ -141,12 +421,41 @@ func buildUIElements() {
 	clusterTable = buildClusterTable()
 
 	// Build the cluster detail pages and add their view shortcuts
-	clusterDetailsPageMap['1'] = pages.NewServicesPage()
-	clusterDetailsPageMap['2'] = pages.NewTasksPage()
-	clusterDetailsPageMap['3'] = pages.NewInstancesPage()
+	clusterDetailsPageMap['1'] = pages.NewServicesPage(userConfig)
+	clusterDetailsPageMap['2'] = pages.NewTasksPage(userConfig)
+	clusterDetailsPageMap['3'] = pages.NewInstancesPage(userConfig)
+	clusterDetailsPageMap['4'] = pages.NewStoppedTasksPage(userConfig)
+	clusterDetailsPageMap['5'] = pages.NewTaskDefinitionsPage(userConfig, runDetailsLoad)
+
+	// Add the actions the user can take on the pages' rows
+	pageActions["Services"] = []pageAction{
+		{'c', "Scale", false, scaleSelectedService},
+		{'f', "Force-Deploy", false, forceDeploySelectedService},
+		{'b', "Rollback", false, rollbackSelectedService},
+	}
+	pageActions["Tasks"] = []pageAction{
+		{'x', "Stop", true, stopMarkedTasks},
+	}
+	pageActions["Instances"] = []pageAction{
+		{'d', "Drain", true, drainMarkedInstances},
+		{'a', "Activate", true, activateMarkedInstances},
+		{'u', "Update-Agent", true, updateMarkedInstanceAgents},
+		{'U', "Update-All", false, updateOutdatedInstanceAgents},
+	}
+
 	clusterDetailsPages = tview.NewPages()
 	for _, page := range clusterDetailsPageMap {
+		page := page
 		page.GetTable().SetBorderColor(tcell.ColorGoldenrod)
+		if page.Details != nil {
+			page.GetTable().
+				SetSelectable(true, false).
+				SetFixed(1, 0).
+				SetSelectedFunc(func(row, column int) { showDetails(page, row) })
+			for column := 0; column < page.GetTable().GetColumnCount(); column++ {
+				page.GetTable().GetCell(0, column).SetSelectable(false)
+			}
+		}
 		clusterDetailsPages.AddPage(page.Name, page.GetTable(), true, false)
 	}
 
@@ -158,11 +467,6 @@ func buildUIElements() {
 		SetTextAlign(ui.R)
 	progressFooterBar.SetBorderPadding(0, 0, 1, 2)
 
-	if clusterTable.GetRowCount() == 1 {
-		commandFooterBar.Clear()
-		fmt.Fprint(commandFooterBar, "No clusters found")
-	}
-
 	footer := tview.NewFlex().SetDirection(tview.FlexColumn).
 		AddItem(commandFooterBar, 0, 6, false).
 		AddItem(progressFooterBar, 0, 4, false)
@@ -173,16 +477,76 @@ func buildUIElements() {
 		AddItem(clusterDetailsPages, 0, 1, false).
 		AddItem(footer, 1, 1, false)
 
+	rootPages = tview.NewPages().
+		AddPage(mainPageName, flex, true, true)
+
 	tviewApp = tview.NewApplication().
-		SetRoot(flex, true).
+		SetRoot(rootPages, true).
 		SetInputCapture(handleAppInput).
 		EnableMouse(true)
 
-	// Show the services page, but start with the cluster table selected
-	selectClusterDetailsPageByKey('1')
+	loadClusterTable()
+}
+
+// Load the ECS clusters in the background, then show them in the cluster table
+func loadClusterTable() {
+	var ecsClusters []*aws.EcsCluster
+	var err error
+	startLoad("clusters", nil, func(ctx context.Context, progress ecsview.ProgressFunc) {
+		ecsClusters, err = ecsview.GetClusters(ctx, progress)
+	}, func() {
+		if err != nil {
+			showClusterLoadError(err)
+			return
+		}
+		renderClusterTable(clusterTable, ecsClusters)
+		updateCommandFooterBar()
+		if clusterTableRows.Total() == 0 {
+			footerClusterData = nil
+			progressFooterBar.Clear()
+			return
+		}
+		clusterTable.Select(1, 0)
+
+		// Show the services page (or the page the user was viewing), but start with the cluster table selected
+		key := int32('1')
+		if highlights := commandFooterBar.GetHighlights(); len(highlights) > 0 {
+			key = int32(highlights[0][0])
+		}
+		selectClusterDetailsPageByKey(key)
+	})
+}
+
+// Reload the cluster table, eg after the clusters failed to load
+func reloadClusters() {
+	ecsview.ClearClusters()
+	loadClusterTable()
+}
+
+// Show the page shortcuts in the command footer bar, or a notice if there are no clusters to view
+func updateCommandFooterBar() {
+	if currentDetails != nil {
+		writeDetailsFooterText()
+		return
+	}
+	if clusterTableRows.Total() == 0 {
+		commandFooterBar.Clear()
+		fmt.Fprint(commandFooterBar, "No clusters found")
+		return
+	}
+	highlights := commandFooterBar.GetHighlights()
+	writeCommandFooterText(commandFooterBar)
+	commandFooterBar.Highlight(highlights...)
+}
+
+// Report that the list of clusters could not be loaded
+func showClusterLoadError(err error) {
+	footerClusterData = nil
+	showLoadError("Unable to load clusters")
+	showErrorModal(err, reloadClusters)
 }
 
-// Load the ECS clusters and create the cluster table
+// Create the cluster table
 func buildClusterTable() *tview.Table {
 
 	table := tview.NewTable().
@@ -197,16 +561,26 @@ func buildClusterTable() *tview.Table {
 	table.SetSelectionChangedFunc(func(row, column int) {
 		renderCurrentClusterDetailsPage()
 	})
+	clusterTableRows = ui.NewTableRows(table)
+	renderClusterTable(table, nil)
+
+	return table
+}
+
+// Render the ECS clusters in the cluster table
+func renderClusterTable(table *tview.Table, ecsClusters []*aws.EcsCluster) {
 
-	expansions := []int{2, 1, 1, 1, 1, 1, 1, 1}
-	alignment := []int{ui.L, ui.L, ui.L, ui.R, ui.R, ui.R, ui.C, ui.C}
+	table.Clear()
 
-	headers := []string{"Name", "Status", "Type", "Instances", "Services", "Tasks", "CPU", "Memory"}
+	expansions := []int{2, 1, 1, 1, 1, 1, 1, 1, 1, 1}
+	alignment := []int{ui.L, ui.L, ui.L, ui.L, ui.L, ui.R, ui.R, ui.R, ui.C, ui.C}
+
+	headers := []string{"Name", "Account", "Region", "Status", "Type", "Instances", "Services", "Tasks", "CPU", "Memory"}
 	ui.AddTableData(table, 0, [][]string{headers}, alignment, expansions, tcell.ColorYellow, false)
 
-	ecsClusters := ecsview.GetClusters()
 	if len(ecsClusters) == 0 {
-		return table
+		clusterTableRows.Update()
+		return
 	}
 
 	data := funk.Map(ecsClusters, func(cluster *aws.EcsCluster) []string {
@@ -218,8 +592,15 @@ func buildClusterTable() *tview.Table {
 		cpuMeter := utils.BuildAsciiMeterCurrentTotal(usage.CpuUsed, usage.CpuTotal, meterWidth)
 		memoryMeter := utils.BuildAsciiMeterCurrentTotal(usage.MemoryUsed, usage.MemoryTotal, meterWidth)
 
+		account := cluster.AccountId
+		if cluster.Profile != "" {
+			account = fmt.Sprintf("%s (%s)", account, cluster.Profile)
+		}
+
 		return []string{
 			*cluster.ClusterName,
+			account,
+			cluster.Region,
 			utils.LowerTitle(*cluster.Status),
 			cluster.GetClusterType(),
 			utils.I64ToString(*cluster.RegisteredContainerInstancesCount),
@@ -246,7 +627,7 @@ func buildClusterTable() *tview.Table {
 		table.GetCell(row+1, 0).SetReference(cluster)
 	}
 
-	return table
+	clusterTableRows.Update()
 }
 
 // Build the command bar with detail page shortcuts that appears in the footer
@@ -256,6 +637,13 @@ func buildCommandFooterBar() *tview.TextView {
 		SetDynamicColors(true).
 		SetRegions(true).
 		SetWrap(false)
+	writeCommandFooterText(footerBar)
+
+	return footerBar
+}
+
+// Write the detail page shortcuts and other commands into the command footer bar
+func writeCommandFooterText(footerBar *tview.TextView) {
 
 	pageCommands := make([]string, 0)
 	for key, page := range clusterDetailsPageMap {
@@ -264,10 +652,16 @@ func buildCommandFooterBar() *tview.TextView {
 	sort.Strings(pageCommands)
 
 	footerPageText := strings.Join(pageCommands, " ")
+	if actionsText := pageActionsFooterText(); actionsText != "" {
+		footerPageText = fmt.Sprintf(`%s %c %s`, footerPageText, tcell.RuneVLine, actionsText)
+	}
 	footerPageText = fmt.Sprintf(`%s %c [white::b]R[darkcyan::-] Refresh-Data`, footerPageText, tcell.RuneVLine)
+	footerPageText = fmt.Sprintf(`%s [white::b]E[darkcyan::-] Regions`, footerPageText)
+	footerPageText = fmt.Sprintf(`%s [white::b]P[darkcyan::-] Profiles`, footerPageText)
+	footerPageText = fmt.Sprintf(`%s [white::b]/[darkcyan::-] Filter`, footerPageText)
+	footerPageText = fmt.Sprintf(`%s [white::b]s/S[darkcyan::-] Sort`, footerPageText)
 	footerPageText = fmt.Sprintf(`%s [white::b]Tab / Mouse[darkcyan::-] Navigate`, footerPageText)
 
+	footerBar.Clear()
 	fmt.Fprint(footerBar, footerPageText)
-
-	return footerBar
 }
@@ -2,37 +2,87 @@ package aws
 
 import (
 	"context"
+	"sync"
 
 	"github.com/google/go-github/v33/github"
 
 	"github.com/swartzrock/ecsview/cmd/utils"
 
-	"github.com/aws/aws-sdk-go/aws/session"
+	awssdk "github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/ecs"
+	"github.com/aws/aws-sdk-go/service/ecs/ecsiface"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/aws/aws-sdk-go/service/sts/stsiface"
 )
 
-var sess *session.Session
+// A Backend that reads from the AWS ECS API using the shared AWS config and credentials
+type SdkBackend struct {
+	client    ecsiface.ECSAPI
+	stsClient stsiface.STSAPI
+	pool      *WorkPool
+	region    string
+	profile   string
 
-func init() {
-	sess = session.Must(session.NewSessionWithOptions(session.Options{
-		SharedConfigState: session.SharedConfigEnable,
-	}))
+	accountMutex sync.Mutex
+	accountId    string
+}
+
+// Returns a Backend using a session built from the shared AWS config and the given overrides.
+// Requests that fan out, eg describing each task definition, are run on the given pool.
+func NewSdkBackend(config SessionConfig, pool *WorkPool) (*SdkBackend, error) {
+	sess, err := newSession(config)
+	if err != nil {
+		return nil, err
+	}
+	backend := NewSdkBackendWithClient(ecs.New(sess), sts.New(sess), pool, awssdk.StringValue(sess.Config.Region))
+	backend.profile = config.Profile
+	return backend, nil
+}
+
+// Returns a Backend using the given ECS and STS clients for the given region, eg stubs in a unit test
+func NewSdkBackendWithClient(client ecsiface.ECSAPI, stsClient stsiface.STSAPI, pool *WorkPool, region string) *SdkBackend {
+	return &SdkBackend{client: client, stsClient: stsClient, pool: pool, region: region}
+}
+
+// Return the AWS region this backend reads from
+func (s *SdkBackend) Region() string {
+	return s.region
+}
+
+// Return the shared config profile this backend reads with, or an empty string for the default credentials
+func (s *SdkBackend) Profile() string {
+	return s.profile
+}
+
+// Return the id of the AWS account this backend's credentials belong to, reading it from STS only once
+func (s *SdkBackend) GetAccountId(ctx context.Context) (string, error) {
+	s.accountMutex.Lock()
+	defer s.accountMutex.Unlock()
+
+	if s.accountId == "" {
+		identity, err := s.stsClient.GetCallerIdentityWithContext(ctx, &sts.GetCallerIdentityInput{})
+		if err != nil {
+			return "", err
+		}
+		s.accountId = awssdk.StringValue(identity.Account)
+	}
+	return s.accountId, nil
 }
 
 // Return a slice of the ECS clusters in the current AWS account
-func DescribeClusters() ([]*ecs.Cluster, error) {
+func (s *SdkBackend) DescribeClusters(ctx context.Context) ([]*ecs.Cluster, error) {
 
-	client := ecs.New(sess)
+	client := s.client
 	var describeErr error
 
 	var clusters []*ecs.Cluster
 	include := "STATISTICS"
 
-	err := client.ListClustersPagesWithContext(context.Background(), &ecs.ListClustersInput{}, func(output *ecs.ListClustersOutput, b bool) bool {
+	err := client.ListClustersPagesWithContext(ctx, &ecs.ListClustersInput{}, func(output *ecs.ListClustersOutput, b bool) bool {
 		if len(output.ClusterArns) == 0 {
 			return false
 		}
-		clusterDetails, err := client.DescribeClusters(&ecs.DescribeClustersInput{
+		clusterDetails, err := client.DescribeClustersWithContext(ctx, &ecs.DescribeClustersInput{
 			Clusters: output.ClusterArns,
 			Include:  []*string{&include},
 		})
@@ -54,19 +104,20 @@ func DescribeClusters() ([]*ecs.Cluster, error) {
 }
 
 // Return a slice of the services in the given ECS cluster
-func DescribeClusterServices(c *ecs.Cluster) ([]*ecs.Service, error) {
-	client := ecs.New(sess)
+func (s *SdkBackend) DescribeClusterServices(ctx context.Context, c *ecs.Cluster) ([]*ecs.Service, error) {
+	client := s.client
 	var describeErr error
 
 	var services []*ecs.Service
 
-	err := client.ListServicesPagesWithContext(context.Background(), &ecs.ListServicesInput{Cluster: c.ClusterArn}, func(output *ecs.ListServicesOutput, b bool) bool {
+	err := client.ListServicesPagesWithContext(ctx, &ecs.ListServicesInput{Cluster: c.ClusterArn}, func(output *ecs.ListServicesOutput, b bool) bool {
 		if len(output.ServiceArns) == 0 {
 			return false
 		}
-		serviceDetails, err := client.DescribeServices(&ecs.DescribeServicesInput{
+		serviceDetails, err := client.DescribeServicesWithContext(ctx, &ecs.DescribeServicesInput{
 			Cluster:  c.ClusterArn,
 			Services: output.ServiceArns,
+			Include:  awssdk.StringSlice([]string{ecs.ServiceFieldTags}),
 		})
 		if err != nil {
 			describeErr = err
@@ -86,19 +137,30 @@ func DescribeClusterServices(c *ecs.Cluster) ([]*ecs.Service, error) {
 }
 
 // Return a slice of the tasks in the given ECS cluster
-func DescribeClusterTasks(c *ecs.Cluster) ([]*ecs.Task, error) {
-	client := ecs.New(sess)
+func (s *SdkBackend) DescribeClusterTasks(ctx context.Context, c *ecs.Cluster) ([]*ecs.Task, error) {
+	return s.describeClusterTasks(ctx, c, ecs.DesiredStatusRunning)
+}
+
+// Return a slice of the recently stopped tasks in the given ECS cluster
+func (s *SdkBackend) DescribeClusterStoppedTasks(ctx context.Context, c *ecs.Cluster) ([]*ecs.Task, error) {
+	return s.describeClusterTasks(ctx, c, ecs.DesiredStatusStopped)
+}
+
+func (s *SdkBackend) describeClusterTasks(ctx context.Context, c *ecs.Cluster, desiredStatus string) ([]*ecs.Task, error) {
+	client := s.client
 	var describeErr error
 
 	var tasks []*ecs.Task
 
-	err := client.ListTasksPagesWithContext(context.Background(), &ecs.ListTasksInput{Cluster: c.ClusterArn}, func(output *ecs.ListTasksOutput, b bool) bool {
+	input := &ecs.ListTasksInput{Cluster: c.ClusterArn, DesiredStatus: &desiredStatus}
+	err := client.ListTasksPagesWithContext(ctx, input, func(output *ecs.ListTasksOutput, b bool) bool {
 		if len(output.TaskArns) == 0 {
 			return false
 		}
-		taskDetails, err := client.DescribeTasks(&ecs.DescribeTasksInput{
+		taskDetails, err := client.DescribeTasksWithContext(ctx, &ecs.DescribeTasksInput{
 			Cluster: c.ClusterArn,
 			Tasks:   output.TaskArns,
+			Include: awssdk.StringSlice([]string{ecs.TaskFieldTags}),
 		})
 		if err != nil {
 			describeErr = err
@@ -118,45 +180,98 @@ func DescribeClusterTasks(c *ecs.Cluster) ([]*ecs.Task, error) {
 }
 
 // Return a slice of the task definitions in the given ECS tasks
-func GetTaskDefinitions(tasks []*ecs.Task) ([]*ecs.TaskDefinition, error) {
+func (s *SdkBackend) GetTaskDefinitions(ctx context.Context, tasks []*ecs.Task) ([]*ecs.TaskDefinition, error) {
 
 	// Dedupe the task definitions by arn
-	taskDefArns := make(map[string]bool)
+	taskDefArnSet := make(map[string]bool)
+	taskDefArns := make([]string, 0)
 	for _, task := range tasks {
-		taskDefArns[*task.TaskDefinitionArn] = true
+		if !taskDefArnSet[*task.TaskDefinitionArn] {
+			taskDefArnSet[*task.TaskDefinitionArn] = true
+			taskDefArns = append(taskDefArns, *task.TaskDefinitionArn)
+		}
 	}
 
-	client := ecs.New(sess)
-	taskDefinitions := make([]*ecs.TaskDefinition, 0)
-	for taskDefArn := range taskDefArns {
-		output, err := client.DescribeTaskDefinition(&ecs.DescribeTaskDefinitionInput{TaskDefinition: &taskDefArn})
+	taskDefinitions := make([]*ecs.TaskDefinition, len(taskDefArns))
+	err := s.pool.Run(ctx, len(taskDefArns), func(ctx context.Context, i int) error {
+		output, err := s.client.DescribeTaskDefinitionWithContext(ctx, &ecs.DescribeTaskDefinitionInput{TaskDefinition: &taskDefArns[i]})
 		if err != nil {
-			return nil, err
+			return err
 		}
-		taskDefinitions = append(taskDefinitions, output.TaskDefinition)
+		taskDefinitions[i] = output.TaskDefinition
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return taskDefinitions, nil
 }
 
+// Return the names of the active task definition families
+func (s *SdkBackend) ListTaskDefinitionFamilies(ctx context.Context) ([]string, error) {
+	families := make([]string, 0)
+	input := &ecs.ListTaskDefinitionFamiliesInput{Status: awssdk.String(ecs.TaskDefinitionFamilyStatusActive)}
+	err := s.client.ListTaskDefinitionFamiliesPagesWithContext(ctx, input, func(output *ecs.ListTaskDefinitionFamiliesOutput, b bool) bool {
+		families = append(families, awssdk.StringValueSlice(output.Families)...)
+		return true
+	})
+	return families, err
+}
+
+// Return the arns of the active revisions of the given task definition family, newest first
+func (s *SdkBackend) ListTaskDefinitionRevisions(ctx context.Context, family string) ([]string, error) {
+	revisions := make([]string, 0)
+	input := &ecs.ListTaskDefinitionsInput{
+		FamilyPrefix: awssdk.String(family),
+		Status:       awssdk.String(ecs.TaskDefinitionStatusActive),
+		Sort:         awssdk.String(ecs.SortOrderDesc),
+	}
+	err := s.client.ListTaskDefinitionsPagesWithContext(ctx, input, func(output *ecs.ListTaskDefinitionsOutput, b bool) bool {
+		// The family is a prefix, so skip the revisions of longer family names
+		for _, arn := range output.TaskDefinitionArns {
+			if TaskDefinitionFamily(*arn) == family {
+				revisions = append(revisions, *arn)
+			}
+		}
+		return true
+	})
+	return revisions, err
+}
+
+// Return the task definition with the given arn
+func (s *SdkBackend) DescribeTaskDefinition(ctx context.Context, taskDefinitionArn string) (*ecs.TaskDefinition, error) {
+	output, err := s.client.DescribeTaskDefinitionWithContext(ctx, &ecs.DescribeTaskDefinitionInput{TaskDefinition: &taskDefinitionArn})
+	if err != nil {
+		return nil, err
+	}
+	return output.TaskDefinition, nil
+}
+
 // Return a short version of the task definition arn
 func ShortenTaskDefArn(taskDefinitionArn *string) string {
 	return utils.RemoveAllRegex(`.*/`, *taskDefinitionArn)
 }
 
+// Return the family of the task definition arn, eg "web" for "arn:aws:ecs:us-east-1:123456789012:task-definition/web:42"
+func TaskDefinitionFamily(taskDefinitionArn string) string {
+	return utils.RemoveAllRegex(`:\d+$`, ShortenTaskDefArn(&taskDefinitionArn))
+}
+
 // Return a slice of the container instances in the given ECS cluster
-func DescribeContainerInstances(c *ecs.Cluster) ([]*ecs.ContainerInstance, error) {
-	client := ecs.New(sess)
+func (s *SdkBackend) DescribeContainerInstances(ctx context.Context, c *ecs.Cluster) ([]*ecs.ContainerInstance, error) {
+	client := s.client
 	var describeErr error
 	var containerInstances []*ecs.ContainerInstance
 
-	err := client.ListContainerInstancesPagesWithContext(context.Background(), &ecs.ListContainerInstancesInput{Cluster: c.ClusterArn}, func(output *ecs.ListContainerInstancesOutput, b bool) bool {
+	err := client.ListContainerInstancesPagesWithContext(ctx, &ecs.ListContainerInstancesInput{Cluster: c.ClusterArn}, func(output *ecs.ListContainerInstancesOutput, b bool) bool {
 		if len(output.ContainerInstanceArns) == 0 {
 			return false
 		}
-		containerDetails, err := client.DescribeContainerInstances(&ecs.DescribeContainerInstancesInput{
+		containerDetails, err := client.DescribeContainerInstancesWithContext(ctx, &ecs.DescribeContainerInstancesInput{
 			Cluster:            c.ClusterArn,
 			ContainerInstances: output.ContainerInstanceArns,
+			Include:            awssdk.StringSlice([]string{ecs.ContainerInstanceFieldTags}),
 		})
 		if err != nil {
 			describeErr = err
@@ -175,10 +290,88 @@ func DescribeContainerInstances(c *ecs.Cluster) ([]*ecs.ContainerInstance, error
 	return containerInstances, err
 }
 
+// Set the number of tasks the given service keeps running, returning the updated service
+func (s *SdkBackend) UpdateServiceDesiredCount(ctx context.Context, c *ecs.Cluster, serviceArn string, desiredCount int64) (*ecs.Service, error) {
+	output, err := s.client.UpdateServiceWithContext(ctx, &ecs.UpdateServiceInput{
+		Cluster:      c.ClusterArn,
+		Service:      &serviceArn,
+		DesiredCount: &desiredCount,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return output.Service, nil
+}
+
+// Start a new deployment of the given service with its current task definition, returning the updated service
+func (s *SdkBackend) ForceNewDeployment(ctx context.Context, c *ecs.Cluster, serviceArn string) (*ecs.Service, error) {
+	output, err := s.client.UpdateServiceWithContext(ctx, &ecs.UpdateServiceInput{
+		Cluster:            c.ClusterArn,
+		Service:            &serviceArn,
+		ForceNewDeployment: awssdk.Bool(true),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return output.Service, nil
+}
+
+// Deploy the given task definition to the given service, returning the updated service
+func (s *SdkBackend) UpdateServiceTaskDefinition(ctx context.Context, c *ecs.Cluster, serviceArn string, taskDefinitionArn string) (*ecs.Service, error) {
+	output, err := s.client.UpdateServiceWithContext(ctx, &ecs.UpdateServiceInput{
+		Cluster:        c.ClusterArn,
+		Service:        &serviceArn,
+		TaskDefinition: &taskDefinitionArn,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return output.Service, nil
+}
+
+// Stop the given task with the given reason, returning the stopped task
+func (s *SdkBackend) StopTask(ctx context.Context, c *ecs.Cluster, taskArn string, reason string) (*ecs.Task, error) {
+	output, err := s.client.StopTaskWithContext(ctx, &ecs.StopTaskInput{
+		Cluster: c.ClusterArn,
+		Task:    &taskArn,
+		Reason:  &reason,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return output.Task, nil
+}
+
+// Set the status of the given container instances to ACTIVE or DRAINING, returning the updated instances and the
+// failures for those that couldn't be updated
+func (s *SdkBackend) UpdateContainerInstancesState(ctx context.Context, c *ecs.Cluster, containerInstanceArns []string, status string) ([]*ecs.ContainerInstance, []*ecs.Failure, error) {
+	output, err := s.client.UpdateContainerInstancesStateWithContext(ctx, &ecs.UpdateContainerInstancesStateInput{
+		Cluster:            c.ClusterArn,
+		ContainerInstances: awssdk.StringSlice(containerInstanceArns),
+		Status:             &status,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return output.ContainerInstances, output.Failures, nil
+}
+
+// Start updating the ECS agent on the given container instance to the latest version, returning the updated instance
+func (s *SdkBackend) UpdateContainerAgent(ctx context.Context, c *ecs.Cluster, containerInstanceArn string) (*ecs.ContainerInstance, error) {
+	output, err := s.client.UpdateContainerAgentWithContext(ctx, &ecs.UpdateContainerAgentInput{
+		Cluster:           c.ClusterArn,
+		ContainerInstance: &containerInstanceArn,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return output.ContainerInstance, nil
+}
+
 // Read the latest released ECS Agent from Github
-func GetLatestECSAgentVersion() (*string, error) {
+func GetLatestECSAgentVersion(ctx context.Context) (*string, error) {
 	githubClient := github.NewClient(nil)
-	releases, _, err := githubClient.Repositories.ListReleases(context.Background(), "aws", "amazon-ecs-agent", nil)
+	releases, _, err := githubClient.Repositories.ListReleases(ctx, "aws", "amazon-ecs-agent", nil)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,288 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	awssdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/ecs"
+	"github.com/aws/aws-sdk-go/service/ecs/ecsiface"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/aws/aws-sdk-go/service/sts/stsiface"
+)
+
+// An ECS client that serves canned pages and records the requests it's sent. Calling a method it doesn't stub panics.
+type stubECS struct {
+	ecsiface.ECSAPI
+
+	mutex sync.Mutex
+
+	// The arns returned by each page of a List call, and the errors returned by List and Describe calls
+	pages       [][]string
+	listErr     error
+	describeErr error
+
+	describeCalls     int
+	listTasksInputs   []*ecs.ListTasksInput
+	taskDefArns       []string
+	updateInputs      []*ecs.UpdateServiceInput
+	stopTaskInputs    []*ecs.StopTaskInput
+	instancesFailures []*ecs.Failure
+}
+
+func (s *stubECS) ListClustersPagesWithContext(ctx awssdk.Context, input *ecs.ListClustersInput, fn func(*ecs.ListClustersOutput, bool) bool, opts ...request.Option) error {
+	for i, page := range s.pages {
+		if !fn(&ecs.ListClustersOutput{ClusterArns: awssdk.StringSlice(page)}, i == len(s.pages)-1) {
+			break
+		}
+	}
+	return s.listErr
+}
+
+func (s *stubECS) DescribeClustersWithContext(ctx awssdk.Context, input *ecs.DescribeClustersInput, opts ...request.Option) (*ecs.DescribeClustersOutput, error) {
+	s.describeCalls++
+	if s.describeErr != nil {
+		return nil, s.describeErr
+	}
+	clusters := make([]*ecs.Cluster, 0)
+	for _, arn := range input.Clusters {
+		clusters = append(clusters, &ecs.Cluster{ClusterArn: arn})
+	}
+	return &ecs.DescribeClustersOutput{Clusters: clusters}, nil
+}
+
+func (s *stubECS) ListTasksPagesWithContext(ctx awssdk.Context, input *ecs.ListTasksInput, fn func(*ecs.ListTasksOutput, bool) bool, opts ...request.Option) error {
+	s.listTasksInputs = append(s.listTasksInputs, input)
+	for i, page := range s.pages {
+		if !fn(&ecs.ListTasksOutput{TaskArns: awssdk.StringSlice(page)}, i == len(s.pages)-1) {
+			break
+		}
+	}
+	return s.listErr
+}
+
+func (s *stubECS) DescribeTasksWithContext(ctx awssdk.Context, input *ecs.DescribeTasksInput, opts ...request.Option) (*ecs.DescribeTasksOutput, error) {
+	s.describeCalls++
+	if s.describeErr != nil {
+		return nil, s.describeErr
+	}
+	tasks := make([]*ecs.Task, 0)
+	for _, arn := range input.Tasks {
+		tasks = append(tasks, &ecs.Task{TaskArn: arn})
+	}
+	return &ecs.DescribeTasksOutput{Tasks: tasks}, nil
+}
+
+func (s *stubECS) DescribeTaskDefinitionWithContext(ctx awssdk.Context, input *ecs.DescribeTaskDefinitionInput, opts ...request.Option) (*ecs.DescribeTaskDefinitionOutput, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.taskDefArns = append(s.taskDefArns, *input.TaskDefinition)
+	if s.describeErr != nil {
+		return nil, s.describeErr
+	}
+	return &ecs.DescribeTaskDefinitionOutput{TaskDefinition: &ecs.TaskDefinition{TaskDefinitionArn: input.TaskDefinition}}, nil
+}
+
+func (s *stubECS) ListTaskDefinitionsPagesWithContext(ctx awssdk.Context, input *ecs.ListTaskDefinitionsInput, fn func(*ecs.ListTaskDefinitionsOutput, bool) bool, opts ...request.Option) error {
+	for i, page := range s.pages {
+		if !fn(&ecs.ListTaskDefinitionsOutput{TaskDefinitionArns: awssdk.StringSlice(page)}, i == len(s.pages)-1) {
+			break
+		}
+	}
+	return s.listErr
+}
+
+func (s *stubECS) UpdateServiceWithContext(ctx awssdk.Context, input *ecs.UpdateServiceInput, opts ...request.Option) (*ecs.UpdateServiceOutput, error) {
+	s.updateInputs = append(s.updateInputs, input)
+	return &ecs.UpdateServiceOutput{Service: &ecs.Service{ServiceArn: input.Service, DesiredCount: input.DesiredCount}}, nil
+}
+
+func (s *stubECS) StopTaskWithContext(ctx awssdk.Context, input *ecs.StopTaskInput, opts ...request.Option) (*ecs.StopTaskOutput, error) {
+	s.stopTaskInputs = append(s.stopTaskInputs, input)
+	return &ecs.StopTaskOutput{Task: &ecs.Task{TaskArn: input.Task, StoppedReason: input.Reason}}, nil
+}
+
+func (s *stubECS) UpdateContainerInstancesStateWithContext(ctx awssdk.Context, input *ecs.UpdateContainerInstancesStateInput, opts ...request.Option) (*ecs.UpdateContainerInstancesStateOutput, error) {
+	instances := make([]*ecs.ContainerInstance, 0)
+	for _, arn := range input.ContainerInstances {
+		instances = append(instances, &ecs.ContainerInstance{ContainerInstanceArn: arn, Status: input.Status})
+	}
+	return &ecs.UpdateContainerInstancesStateOutput{ContainerInstances: instances, Failures: s.instancesFailures}, nil
+}
+
+// An STS client that returns an account id and counts how often it's asked
+type stubSTS struct {
+	stsiface.STSAPI
+	calls int
+	err   error
+}
+
+func (s *stubSTS) GetCallerIdentityWithContext(ctx awssdk.Context, input *sts.GetCallerIdentityInput, opts ...request.Option) (*sts.GetCallerIdentityOutput, error) {
+	s.calls++
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &sts.GetCallerIdentityOutput{Account: awssdk.String("123456789012")}, nil
+}
+
+func newTestSdkBackend(client *stubECS) *SdkBackend {
+	return NewSdkBackendWithClient(client, &stubSTS{}, NewWorkPool(DefaultConcurrency), "us-east-1")
+}
+
+func TestSdkBackendDescribeClusters(t *testing.T) {
+	errList := errors.New("AccessDeniedException: list")
+	errDescribe := errors.New("AccessDeniedException: describe")
+	tests := []struct {
+		name          string
+		client        *stubECS
+		wantClusters  int
+		wantDescribes int
+		wantErr       error
+	}{
+		{"no clusters", &stubECS{}, 0, 0, nil},
+		{"one page", &stubECS{pages: [][]string{{"a", "b"}}}, 2, 1, nil},
+		{"two pages", &stubECS{pages: [][]string{{"a", "b"}, {"c"}}}, 3, 2, nil},
+		{"stops at an empty page", &stubECS{pages: [][]string{{"a"}, {}, {"c"}}}, 1, 1, nil},
+		{"list fails", &stubECS{pages: [][]string{{"a"}}, listErr: errList}, 1, 1, errList},
+		{"describe fails", &stubECS{pages: [][]string{{"a"}, {"b"}}, describeErr: errDescribe}, 0, 1, errDescribe},
+	}
+
+	for _, test := range tests {
+		clusters, err := newTestSdkBackend(test.client).DescribeClusters(context.Background())
+		if err != test.wantErr {
+			t.Errorf("%s: err = %v, want %v", test.name, err, test.wantErr)
+		}
+		if len(clusters) != test.wantClusters {
+			t.Errorf("%s: got %d clusters, want %d", test.name, len(clusters), test.wantClusters)
+		}
+		if test.client.describeCalls != test.wantDescribes {
+			t.Errorf("%s: made %d Describe calls, want %d", test.name, test.client.describeCalls, test.wantDescribes)
+		}
+	}
+}
+
+func TestSdkBackendDescribeClusterTasks(t *testing.T) {
+	cluster := &ecs.Cluster{ClusterArn: awssdk.String(testClusterArn)}
+	tests := []struct {
+		name       string
+		describe   func(b *SdkBackend) ([]*ecs.Task, error)
+		wantStatus string
+	}{
+		{"running", func(b *SdkBackend) ([]*ecs.Task, error) { return b.DescribeClusterTasks(context.Background(), cluster) }, ecs.DesiredStatusRunning},
+		{"stopped", func(b *SdkBackend) ([]*ecs.Task, error) {
+			return b.DescribeClusterStoppedTasks(context.Background(), cluster)
+		}, ecs.DesiredStatusStopped},
+	}
+
+	for _, test := range tests {
+		client := &stubECS{pages: [][]string{{"t1", "t2"}, {"t3"}}}
+		tasks, err := test.describe(newTestSdkBackend(client))
+		if err != nil || len(tasks) != 3 {
+			t.Errorf("%s: got %d tasks and %v, want 3 tasks", test.name, len(tasks), err)
+		}
+		if len(client.listTasksInputs) != 1 || *client.listTasksInputs[0].DesiredStatus != test.wantStatus ||
+			*client.listTasksInputs[0].Cluster != testClusterArn {
+			t.Errorf("%s: listed tasks with %v, want the cluster's %s tasks", test.name, client.listTasksInputs, test.wantStatus)
+		}
+	}
+}
+
+func TestSdkBackendGetTaskDefinitions(t *testing.T) {
+	tasks := []*ecs.Task{
+		{TaskDefinitionArn: awssdk.String("web:42")},
+		{TaskDefinitionArn: awssdk.String("worker:17")},
+		{TaskDefinitionArn: awssdk.String("web:42")},
+	}
+
+	client := &stubECS{}
+	taskDefs, err := newTestSdkBackend(client).GetTaskDefinitions(context.Background(), tasks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(taskDefs) != 2 || len(client.taskDefArns) != 2 {
+		t.Errorf("got task definitions %v after describing %v, want each task definition once", taskDefs, client.taskDefArns)
+	}
+
+	errDescribe := errors.New("ClientException: describe")
+	if _, err := newTestSdkBackend(&stubECS{describeErr: errDescribe}).GetTaskDefinitions(context.Background(), tasks); err != errDescribe {
+		t.Errorf("err = %v, want %v", err, errDescribe)
+	}
+}
+
+func TestSdkBackendListTaskDefinitionRevisions(t *testing.T) {
+	client := &stubECS{pages: [][]string{
+		{"arn:aws:ecs:us-east-1:123456789012:task-definition/web:42", "arn:aws:ecs:us-east-1:123456789012:task-definition/web-worker:3"},
+		{"arn:aws:ecs:us-east-1:123456789012:task-definition/web:41"},
+	}}
+	revisions, err := newTestSdkBackend(client).ListTaskDefinitionRevisions(context.Background(), "web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(revisions) != 2 || !strings.HasSuffix(revisions[0], "web:42") || !strings.HasSuffix(revisions[1], "web:41") {
+		t.Errorf("revisions = %v, want web:42 and web:41 without web-worker", revisions)
+	}
+}
+
+func TestSdkBackendGetAccountId(t *testing.T) {
+	stsClient := &stubSTS{}
+	backend := NewSdkBackendWithClient(&stubECS{}, stsClient, NewWorkPool(1), "us-east-1")
+	for i := 0; i < 2; i++ {
+		accountId, err := backend.GetAccountId(context.Background())
+		if err != nil || accountId != "123456789012" {
+			t.Errorf("GetAccountId() = %q, %v", accountId, err)
+		}
+	}
+	if stsClient.calls != 1 {
+		t.Errorf("asked STS %d times, want once", stsClient.calls)
+	}
+
+	errDenied := errors.New("AccessDenied")
+	backend = NewSdkBackendWithClient(&stubECS{}, &stubSTS{err: errDenied}, NewWorkPool(1), "us-east-1")
+	if _, err := backend.GetAccountId(context.Background()); err != errDenied {
+		t.Errorf("err = %v, want %v", err, errDenied)
+	}
+}
+
+func TestSdkBackendActions(t *testing.T) {
+	ctx := context.Background()
+	cluster := &ecs.Cluster{ClusterArn: awssdk.String(testClusterArn)}
+
+	client := &stubECS{}
+	backend := newTestSdkBackend(client)
+	if _, err := backend.UpdateServiceDesiredCount(ctx, cluster, testServiceArn, 5); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := backend.ForceNewDeployment(ctx, cluster, testServiceArn); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := backend.UpdateServiceTaskDefinition(ctx, cluster, testServiceArn, testTaskDefArn); err != nil {
+		t.Fatal(err)
+	}
+	if len(client.updateInputs) != 3 {
+		t.Fatalf("sent %d UpdateService requests, want 3", len(client.updateInputs))
+	}
+	if input := client.updateInputs[0]; *input.Cluster != testClusterArn || *input.Service != testServiceArn || *input.DesiredCount != 5 {
+		t.Errorf("scaled with %v", input)
+	}
+	if input := client.updateInputs[1]; !*input.ForceNewDeployment || input.TaskDefinition != nil {
+		t.Errorf("forced a deployment with %v", input)
+	}
+	if input := client.updateInputs[2]; *input.TaskDefinition != testTaskDefArn || input.DesiredCount != nil {
+		t.Errorf("deployed a task definition with %v", input)
+	}
+
+	task, err := backend.StopTask(ctx, cluster, testTaskArn, "testing")
+	if err != nil || *task.StoppedReason != "testing" || *client.stopTaskInputs[0].Task != testTaskArn {
+		t.Errorf("StopTask() = %v, %v", task, err)
+	}
+
+	client.instancesFailures = []*ecs.Failure{{Arn: awssdk.String("missing"), Reason: awssdk.String("MISSING")}}
+	instances, failures, err := backend.UpdateContainerInstancesState(ctx, cluster, []string{testInstanceArn}, ecs.ContainerInstanceStatusDraining)
+	if err != nil || len(instances) != 1 || *instances[0].Status != ecs.ContainerInstanceStatusDraining || len(failures) != 1 {
+		t.Errorf("UpdateContainerInstancesState() = %v, %v, %v", instances, failures, err)
+	}
+}
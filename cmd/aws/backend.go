@@ -0,0 +1,66 @@
+package aws
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go/service/ecs"
+)
+
+// Provides the ECS data displayed by ecsview. The AWS SDK and in-memory fixtures are both Backends.
+type Backend interface {
+
+	// Return the AWS region this backend reads from
+	Region() string
+
+	// Return the shared config profile this backend reads with, or an empty string for the default credentials
+	Profile() string
+
+	// Return the id of the AWS account this backend reads from
+	GetAccountId(ctx context.Context) (string, error)
+
+	// Return a slice of the ECS clusters in the current AWS account
+	DescribeClusters(ctx context.Context) ([]*ecs.Cluster, error)
+
+	// Return a slice of the services in the given ECS cluster
+	DescribeClusterServices(ctx context.Context, c *ecs.Cluster) ([]*ecs.Service, error)
+
+	// Return a slice of the tasks in the given ECS cluster
+	DescribeClusterTasks(ctx context.Context, c *ecs.Cluster) ([]*ecs.Task, error)
+
+	// Return a slice of the recently stopped tasks in the given ECS cluster
+	DescribeClusterStoppedTasks(ctx context.Context, c *ecs.Cluster) ([]*ecs.Task, error)
+
+	// Return a slice of the task definitions in the given ECS tasks
+	GetTaskDefinitions(ctx context.Context, tasks []*ecs.Task) ([]*ecs.TaskDefinition, error)
+
+	// Return the names of the active task definition families
+	ListTaskDefinitionFamilies(ctx context.Context) ([]string, error)
+
+	// Return the arns of the active revisions of the given task definition family, newest first
+	ListTaskDefinitionRevisions(ctx context.Context, family string) ([]string, error)
+
+	// Return the task definition with the given arn
+	DescribeTaskDefinition(ctx context.Context, taskDefinitionArn string) (*ecs.TaskDefinition, error)
+
+	// Return a slice of the container instances in the given ECS cluster
+	DescribeContainerInstances(ctx context.Context, c *ecs.Cluster) ([]*ecs.ContainerInstance, error)
+
+	// Set the number of tasks the given service keeps running, returning the updated service
+	UpdateServiceDesiredCount(ctx context.Context, c *ecs.Cluster, serviceArn string, desiredCount int64) (*ecs.Service, error)
+
+	// Start a new deployment of the given service with its current task definition, returning the updated service
+	ForceNewDeployment(ctx context.Context, c *ecs.Cluster, serviceArn string) (*ecs.Service, error)
+
+	// Deploy the given task definition to the given service, returning the updated service
+	UpdateServiceTaskDefinition(ctx context.Context, c *ecs.Cluster, serviceArn string, taskDefinitionArn string) (*ecs.Service, error)
+
+	// Stop the given task with the given reason, returning the stopped task
+	StopTask(ctx context.Context, c *ecs.Cluster, taskArn string, reason string) (*ecs.Task, error)
+
+	// Set the status of the given container instances to ACTIVE or DRAINING, returning the updated instances and the
+	// failures for those that couldn't be updated
+	UpdateContainerInstancesState(ctx context.Context, c *ecs.Cluster, containerInstanceArns []string, status string) ([]*ecs.ContainerInstance, []*ecs.Failure, error)
+
+	// Start updating the ECS agent on the given container instance to the latest version, returning the updated instance
+	UpdateContainerAgent(ctx context.Context, c *ecs.Cluster, containerInstanceArn string) (*ecs.ContainerInstance, error)
+}
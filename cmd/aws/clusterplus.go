@@ -10,17 +10,31 @@ import (
 // Adds helpful functions to an ecs.Cluster
 type EcsCluster struct {
 	*ecs.Cluster
+
+	// The AWS region the cluster was loaded from
+	Region string
+
+	// The shared config profile the cluster was loaded with, or an empty string for the default credentials
+	Profile string
+
+	// The id of the AWS account the cluster belongs to
+	AccountId string
 }
 
-func NewEcsCluster(cluster *ecs.Cluster) *EcsCluster {
+func NewEcsCluster(cluster *ecs.Cluster, region string) *EcsCluster {
+	if region == "" {
+		region = RegionFromArn(*cluster.ClusterArn)
+	}
 	return &EcsCluster{
-		cluster,
+		Cluster:   cluster,
+		Region:    region,
+		AccountId: AccountIdFromArn(*cluster.ClusterArn),
 	}
 }
 
-func NewEcsClusters(clusters []*ecs.Cluster) []*EcsCluster {
+func NewEcsClusters(clusters []*ecs.Cluster, region string) []*EcsCluster {
 	return funk.Map(clusters, func(c *ecs.Cluster) *EcsCluster {
-		return NewEcsCluster(c)
+		return NewEcsCluster(c, region)
 	}).([]*EcsCluster)
 }
 
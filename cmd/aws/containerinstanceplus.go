@@ -75,3 +75,20 @@ func (i *EcsContainer) GetAttribute(name string) *string {
 	}
 	return nil
 }
+
+// Returns true if the instance's agent is older than the latest version, or false if the latest version isn't known
+func (i *EcsContainer) AgentOutdated(latestAgentVersion *string) bool {
+	if latestAgentVersion == nil || i.VersionInfo == nil || i.VersionInfo.AgentVersion == nil {
+		return false
+	}
+	return *i.VersionInfo.AgentVersion != *latestAgentVersion
+}
+
+// Returns true if an agent update with the status hasn't finished or failed yet
+func AgentUpdateInProgress(status *string) bool {
+	if status == nil {
+		return false
+	}
+	return funk.ContainsString([]string{ecs.AgentUpdateStatusPending, ecs.AgentUpdateStatusStaging,
+		ecs.AgentUpdateStatusStaged, ecs.AgentUpdateStatusUpdating}, *status)
+}
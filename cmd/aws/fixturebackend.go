@@ -0,0 +1,396 @@
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"sync"
+	"time"
+
+	awssdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ecs"
+	"github.com/thoas/go-funk"
+)
+
+// A Backend that serves canned ECS data from memory, for unit tests and for running without AWS credentials
+type FixtureBackend struct {
+	Clusters        []*FixtureCluster     `json:"clusters"`
+	TaskDefinitions []*ecs.TaskDefinition `json:"taskDefinitions"`
+
+	// Limits the clusters to those in this region, unless empty
+	region string
+}
+
+// The canned contents of a single ECS cluster
+type FixtureCluster struct {
+	Cluster            *ecs.Cluster             `json:"cluster"`
+	Services           []*ecs.Service           `json:"services"`
+	Tasks              []*ecs.Task              `json:"tasks"`
+	ContainerInstances []*ecs.ContainerInstance `json:"containerInstances"`
+
+	// Guards the slices above, which actions replace the objects in. The objects themselves are never modified, as
+	// they may be displayed while an action runs.
+	mutex sync.Mutex
+}
+
+// Reads a FixtureBackend from a JSON file. The ECS objects use the AWS SDK field names, eg "ClusterArn".
+func LoadFixtureBackend(path string) (*FixtureBackend, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	backend := &FixtureBackend{}
+	if err := json.Unmarshal(contents, backend); err != nil {
+		return nil, fmt.Errorf("unable to parse fixtures file %s: %w", path, err)
+	}
+	return backend, nil
+}
+
+// Returns a copy of the backend with only the clusters whose arns are in the given region
+func (f *FixtureBackend) InRegion(region string) *FixtureBackend {
+	regional := &FixtureBackend{TaskDefinitions: f.TaskDefinitions, region: region}
+	for _, fc := range f.Clusters {
+		if fc.Cluster != nil && RegionFromArn(*fc.Cluster.ClusterArn) == region {
+			regional.Clusters = append(regional.Clusters, fc)
+		}
+	}
+	return regional
+}
+
+// Return the region of the fixture clusters, or an empty string if they aren't limited to one region
+func (f *FixtureBackend) Region() string {
+	return f.region
+}
+
+// Return an empty string, as fixtures aren't read with a profile
+func (f *FixtureBackend) Profile() string {
+	return ""
+}
+
+// Return the account id in the fixture cluster arns
+func (f *FixtureBackend) GetAccountId(ctx context.Context) (string, error) {
+	for _, fc := range f.Clusters {
+		if fc.Cluster != nil {
+			return AccountIdFromArn(*fc.Cluster.ClusterArn), nil
+		}
+	}
+	return "", nil
+}
+
+func (f *FixtureBackend) findCluster(c *ecs.Cluster) (*FixtureCluster, error) {
+	for _, fc := range f.Clusters {
+		if fc.Cluster != nil && *fc.Cluster.ClusterArn == *c.ClusterArn {
+			return fc, nil
+		}
+	}
+	return nil, fmt.Errorf("ClusterNotFoundException: no fixture for cluster %s", *c.ClusterArn)
+}
+
+// Return a slice of the fixture clusters
+func (f *FixtureBackend) DescribeClusters(ctx context.Context) ([]*ecs.Cluster, error) {
+	clusters := make([]*ecs.Cluster, 0, len(f.Clusters))
+	for _, fc := range f.Clusters {
+		clusters = append(clusters, fc.Cluster)
+	}
+	return clusters, nil
+}
+
+// Return a slice of the fixture services in the given ECS cluster
+func (f *FixtureBackend) DescribeClusterServices(ctx context.Context, c *ecs.Cluster) ([]*ecs.Service, error) {
+	fc, err := f.findCluster(c)
+	if err != nil {
+		return nil, err
+	}
+	fc.mutex.Lock()
+	defer fc.mutex.Unlock()
+	return append([]*ecs.Service{}, fc.Services...), nil
+}
+
+// Return a slice of the fixture tasks in the given ECS cluster that aren't stopped
+func (f *FixtureBackend) DescribeClusterTasks(ctx context.Context, c *ecs.Cluster) ([]*ecs.Task, error) {
+	return f.describeClusterTasks(c, false)
+}
+
+// Return a slice of the fixture tasks in the given ECS cluster whose desired status is STOPPED
+func (f *FixtureBackend) DescribeClusterStoppedTasks(ctx context.Context, c *ecs.Cluster) ([]*ecs.Task, error) {
+	return f.describeClusterTasks(c, true)
+}
+
+func (f *FixtureBackend) describeClusterTasks(c *ecs.Cluster, stopped bool) ([]*ecs.Task, error) {
+	fc, err := f.findCluster(c)
+	if err != nil {
+		return nil, err
+	}
+
+	fc.mutex.Lock()
+	defer fc.mutex.Unlock()
+	tasks := make([]*ecs.Task, 0)
+	for _, task := range fc.Tasks {
+		isStopped := task.DesiredStatus != nil && *task.DesiredStatus == ecs.DesiredStatusStopped
+		if isStopped == stopped {
+			tasks = append(tasks, task)
+		}
+	}
+	return tasks, nil
+}
+
+// Return a slice of the fixture task definitions used by the given ECS tasks
+func (f *FixtureBackend) GetTaskDefinitions(ctx context.Context, tasks []*ecs.Task) ([]*ecs.TaskDefinition, error) {
+	taskDefArns := make(map[string]bool)
+	for _, task := range tasks {
+		taskDefArns[*task.TaskDefinitionArn] = true
+	}
+
+	taskDefinitions := make([]*ecs.TaskDefinition, 0)
+	for _, taskDef := range f.TaskDefinitions {
+		if taskDefArns[*taskDef.TaskDefinitionArn] {
+			taskDefinitions = append(taskDefinitions, taskDef)
+		}
+	}
+	return taskDefinitions, nil
+}
+
+// Return the families of the fixture task definitions in the backend's region
+func (f *FixtureBackend) ListTaskDefinitionFamilies(ctx context.Context) ([]string, error) {
+	families := make([]string, 0)
+	for _, taskDef := range f.regionalTaskDefinitions() {
+		family := TaskDefinitionFamily(*taskDef.TaskDefinitionArn)
+		if !funk.ContainsString(families, family) {
+			families = append(families, family)
+		}
+	}
+	sort.Strings(families)
+	return families, nil
+}
+
+// Return the arns of the fixture task definitions in the given family, newest first
+func (f *FixtureBackend) ListTaskDefinitionRevisions(ctx context.Context, family string) ([]string, error) {
+	revisions := make([]*ecs.TaskDefinition, 0)
+	for _, taskDef := range f.regionalTaskDefinitions() {
+		if TaskDefinitionFamily(*taskDef.TaskDefinitionArn) == family {
+			revisions = append(revisions, taskDef)
+		}
+	}
+	sort.SliceStable(revisions, func(i, j int) bool {
+		return *revisions[i].Revision > *revisions[j].Revision
+	})
+	return funk.Map(revisions, func(taskDef *ecs.TaskDefinition) string {
+		return *taskDef.TaskDefinitionArn
+	}).([]string), nil
+}
+
+// Return the fixture task definition with the given arn
+func (f *FixtureBackend) DescribeTaskDefinition(ctx context.Context, taskDefinitionArn string) (*ecs.TaskDefinition, error) {
+	for _, taskDef := range f.TaskDefinitions {
+		if *taskDef.TaskDefinitionArn == taskDefinitionArn {
+			return taskDef, nil
+		}
+	}
+	return nil, fmt.Errorf("ClientException: Unable to describe task definition %s", taskDefinitionArn)
+}
+
+// Returns the fixture task definitions in the backend's region, or all of them if it isn't limited to one region
+func (f *FixtureBackend) regionalTaskDefinitions() []*ecs.TaskDefinition {
+	if f.region == "" {
+		return f.TaskDefinitions
+	}
+	taskDefinitions := make([]*ecs.TaskDefinition, 0)
+	for _, taskDef := range f.TaskDefinitions {
+		if RegionFromArn(*taskDef.TaskDefinitionArn) == f.region {
+			taskDefinitions = append(taskDefinitions, taskDef)
+		}
+	}
+	return taskDefinitions
+}
+
+// Return a slice of the fixture container instances in the given ECS cluster
+func (f *FixtureBackend) DescribeContainerInstances(ctx context.Context, c *ecs.Cluster) ([]*ecs.ContainerInstance, error) {
+	fc, err := f.findCluster(c)
+	if err != nil {
+		return nil, err
+	}
+	fc.mutex.Lock()
+	defer fc.mutex.Unlock()
+	fc.advanceAgentUpdates()
+	return append([]*ecs.ContainerInstance{}, fc.ContainerInstances...), nil
+}
+
+// Moves each of the cluster's agent updates on a step, from pending to updating to updated, replacing the instances
+// with copies so instances already returned don't change. Call with the cluster's mutex held.
+func (fc *FixtureCluster) advanceAgentUpdates() {
+	next := map[string]string{
+		ecs.AgentUpdateStatusPending:  ecs.AgentUpdateStatusUpdating,
+		ecs.AgentUpdateStatusUpdating: ecs.AgentUpdateStatusUpdated,
+	}
+	for i, instance := range fc.ContainerInstances {
+		if instance.AgentUpdateStatus == nil {
+			continue
+		}
+		if status, found := next[*instance.AgentUpdateStatus]; found {
+			updated := *instance
+			updated.AgentUpdateStatus = awssdk.String(status)
+			fc.ContainerInstances[i] = &updated
+		}
+	}
+}
+
+// Set the desired count of the fixture service, returning the updated service
+func (f *FixtureBackend) UpdateServiceDesiredCount(ctx context.Context, c *ecs.Cluster, serviceArn string, desiredCount int64) (*ecs.Service, error) {
+	return f.updateService(c, serviceArn, func(service *ecs.Service) {
+		service.DesiredCount = &desiredCount
+	})
+}
+
+// Start a new deployment of the fixture service with its current task definition. The deployment stays in progress,
+// as fixture services don't start or stop tasks.
+func (f *FixtureBackend) ForceNewDeployment(ctx context.Context, c *ecs.Cluster, serviceArn string) (*ecs.Service, error) {
+	return f.updateService(c, serviceArn, func(service *ecs.Service) {
+		startFixtureDeployment(service, *service.TaskDefinition)
+	})
+}
+
+// Start a deployment of the task definition to the fixture service. The deployment stays in progress, as fixture
+// services don't start or stop tasks.
+func (f *FixtureBackend) UpdateServiceTaskDefinition(ctx context.Context, c *ecs.Cluster, serviceArn string, taskDefinitionArn string) (*ecs.Service, error) {
+	return f.updateService(c, serviceArn, func(service *ecs.Service) {
+		startFixtureDeployment(service, taskDefinitionArn)
+	})
+}
+
+// Adds a new primary deployment of the task definition to the service, making its previous primary deployment active
+func startFixtureDeployment(service *ecs.Service, taskDefinitionArn string) {
+	now := time.Now()
+	id := fmt.Sprintf("ecs-svc/%d", now.UnixNano())
+	deployments := []*ecs.Deployment{{
+		Id:                 &id,
+		Status:             awssdk.String("PRIMARY"),
+		TaskDefinition:     &taskDefinitionArn,
+		DesiredCount:       service.DesiredCount,
+		RunningCount:       awssdk.Int64(0),
+		PendingCount:       awssdk.Int64(0),
+		FailedTasks:        awssdk.Int64(0),
+		CreatedAt:          &now,
+		UpdatedAt:          &now,
+		LaunchType:         service.LaunchType,
+		RolloutState:       awssdk.String(ecs.DeploymentRolloutStateInProgress),
+		RolloutStateReason: awssdk.String(fmt.Sprintf("ECS deployment %s in progress.", id)),
+	}}
+	for _, deployment := range service.Deployments {
+		previous := *deployment
+		if *previous.Status == "PRIMARY" {
+			previous.Status = awssdk.String("ACTIVE")
+		}
+		deployments = append(deployments, &previous)
+	}
+	service.Deployments = deployments
+	service.TaskDefinition = &taskDefinitionArn
+}
+
+// Replaces the fixture service with a copy changed by update, returning the copy
+func (f *FixtureBackend) updateService(c *ecs.Cluster, serviceArn string, update func(service *ecs.Service)) (*ecs.Service, error) {
+	fc, err := f.findCluster(c)
+	if err != nil {
+		return nil, err
+	}
+	fc.mutex.Lock()
+	defer fc.mutex.Unlock()
+
+	for i, service := range fc.Services {
+		if *service.ServiceArn == serviceArn {
+			updated := *service
+			update(&updated)
+			fc.Services[i] = &updated
+			return &updated, nil
+		}
+	}
+	return nil, fmt.Errorf("ServiceNotFoundException: no fixture for service %s", serviceArn)
+}
+
+// Stop the fixture task, replacing it with a stopped copy that's listed with the cluster's stopped tasks
+func (f *FixtureBackend) StopTask(ctx context.Context, c *ecs.Cluster, taskArn string, reason string) (*ecs.Task, error) {
+	fc, err := f.findCluster(c)
+	if err != nil {
+		return nil, err
+	}
+	fc.mutex.Lock()
+	defer fc.mutex.Unlock()
+
+	for i, task := range fc.Tasks {
+		if *task.TaskArn != taskArn {
+			continue
+		}
+		if task.DesiredStatus != nil && *task.DesiredStatus == ecs.DesiredStatusStopped {
+			return task, nil
+		}
+		now := time.Now()
+		stopped := *task
+		stopped.DesiredStatus = awssdk.String(ecs.DesiredStatusStopped)
+		stopped.LastStatus = awssdk.String(ecs.DesiredStatusStopped)
+		stopped.StopCode = awssdk.String(ecs.TaskStopCodeUserInitiated)
+		stopped.StoppedReason = &reason
+		stopped.StoppingAt = &now
+		stopped.StoppedAt = &now
+		fc.Tasks[i] = &stopped
+		return &stopped, nil
+	}
+	return nil, fmt.Errorf("InvalidParameterException: The referenced task was not found: %s", taskArn)
+}
+
+// Set the status of the fixture container instances, replacing them with updated copies. Draining instances keep
+// their tasks, as fixture services don't start or stop tasks.
+func (f *FixtureBackend) UpdateContainerInstancesState(ctx context.Context, c *ecs.Cluster, containerInstanceArns []string, status string) ([]*ecs.ContainerInstance, []*ecs.Failure, error) {
+	fc, err := f.findCluster(c)
+	if err != nil {
+		return nil, nil, err
+	}
+	fc.mutex.Lock()
+	defer fc.mutex.Unlock()
+
+	updated := make([]*ecs.ContainerInstance, 0)
+	failures := make([]*ecs.Failure, 0)
+	for _, arn := range containerInstanceArns {
+		found := false
+		for i, instance := range fc.ContainerInstances {
+			if *instance.ContainerInstanceArn == arn {
+				instance := *instance
+				instance.Status = awssdk.String(status)
+				fc.ContainerInstances[i] = &instance
+				updated = append(updated, &instance)
+				found = true
+			}
+		}
+		if !found {
+			failures = append(failures, &ecs.Failure{Arn: awssdk.String(arn), Reason: awssdk.String("MISSING")})
+		}
+	}
+	return updated, failures, nil
+}
+
+// Start an agent update on the fixture container instance, replacing it with a copy whose update is pending. Each
+// later DescribeContainerInstances call moves the update on a step, to updating and then updated, though the agent
+// version stays the same as fixture instances don't run an agent.
+func (f *FixtureBackend) UpdateContainerAgent(ctx context.Context, c *ecs.Cluster, containerInstanceArn string) (*ecs.ContainerInstance, error) {
+	fc, err := f.findCluster(c)
+	if err != nil {
+		return nil, err
+	}
+	fc.mutex.Lock()
+	defer fc.mutex.Unlock()
+
+	for i, instance := range fc.ContainerInstances {
+		if *instance.ContainerInstanceArn != containerInstanceArn {
+			continue
+		}
+		if AgentUpdateInProgress(instance.AgentUpdateStatus) {
+			return nil, fmt.Errorf("UpdateInProgressException: an agent update is already in progress on %s", containerInstanceArn)
+		}
+		updated := *instance
+		updated.AgentUpdateStatus = awssdk.String(ecs.AgentUpdateStatusPending)
+		fc.ContainerInstances[i] = &updated
+		return &updated, nil
+	}
+	return nil, fmt.Errorf("InvalidParameterException: The referenced container instance was not found: %s", containerInstanceArn)
+}
@@ -0,0 +1,428 @@
+package aws
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	awssdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ecs"
+)
+
+const testClusterArn = "arn:aws:ecs:us-east-1:123456789012:cluster/production"
+const testServiceArn = "arn:aws:ecs:us-east-1:123456789012:service/production/web"
+const testTaskArn = "arn:aws:ecs:us-east-1:123456789012:task/production/0000000000000001"
+const testInstanceArn = "arn:aws:ecs:us-east-1:123456789012:container-instance/production/0000000000000001"
+const testTaskDefArn = "arn:aws:ecs:us-east-1:123456789012:task-definition/web:42"
+
+// Returns a backend with a cluster in us-east-1 holding a service, a running task and an instance, a cluster in
+// us-west-2, and task definitions in both regions
+func newTestFixtureBackend() *FixtureBackend {
+	return &FixtureBackend{
+		Clusters: []*FixtureCluster{
+			{
+				Cluster: &ecs.Cluster{ClusterArn: awssdk.String(testClusterArn), ClusterName: awssdk.String("production")},
+				Services: []*ecs.Service{{
+					ServiceArn:     awssdk.String(testServiceArn),
+					ServiceName:    awssdk.String("web"),
+					DesiredCount:   awssdk.Int64(2),
+					TaskDefinition: awssdk.String(testTaskDefArn),
+					Deployments: []*ecs.Deployment{{
+						Id:             awssdk.String("ecs-svc/1"),
+						Status:         awssdk.String("PRIMARY"),
+						TaskDefinition: awssdk.String(testTaskDefArn),
+					}},
+				}},
+				Tasks: []*ecs.Task{{
+					TaskArn:           awssdk.String(testTaskArn),
+					TaskDefinitionArn: awssdk.String(testTaskDefArn),
+					DesiredStatus:     awssdk.String(ecs.DesiredStatusRunning),
+					LastStatus:        awssdk.String(ecs.DesiredStatusRunning),
+				}},
+				ContainerInstances: []*ecs.ContainerInstance{{
+					ContainerInstanceArn: awssdk.String(testInstanceArn),
+					Ec2InstanceId:        awssdk.String("i-0a1b2c3d4e5f60718"),
+					Status:               awssdk.String(ecs.ContainerInstanceStatusActive),
+				}},
+			},
+			{
+				Cluster: &ecs.Cluster{
+					ClusterArn:  awssdk.String("arn:aws:ecs:us-west-2:123456789012:cluster/staging"),
+					ClusterName: awssdk.String("staging"),
+				},
+			},
+		},
+		TaskDefinitions: []*ecs.TaskDefinition{
+			{TaskDefinitionArn: awssdk.String("arn:aws:ecs:us-east-1:123456789012:task-definition/web:41"), Revision: awssdk.Int64(41)},
+			{TaskDefinitionArn: awssdk.String(testTaskDefArn), Revision: awssdk.Int64(42)},
+			{TaskDefinitionArn: awssdk.String("arn:aws:ecs:us-west-2:123456789012:task-definition/api:9"), Revision: awssdk.Int64(9)},
+		},
+	}
+}
+
+func TestFixtureBackendDescribe(t *testing.T) {
+	ctx := context.Background()
+	backend := newTestFixtureBackend()
+	cluster := backend.Clusters[0].Cluster
+	tasks := backend.Clusters[0].Tasks
+	backend.Clusters[0].Tasks = append(tasks, &ecs.Task{
+		TaskArn:           awssdk.String("arn:aws:ecs:us-east-1:123456789012:task/production/0000000000000002"),
+		TaskDefinitionArn: awssdk.String(testTaskDefArn),
+		DesiredStatus:     awssdk.String(ecs.DesiredStatusStopped),
+	})
+
+	tests := []struct {
+		name     string
+		describe func() (int, error)
+		want     int
+	}{
+		{"DescribeClusters", func() (int, error) { clusters, err := backend.DescribeClusters(ctx); return len(clusters), err }, 2},
+		{"DescribeClusterServices", func() (int, error) {
+			services, err := backend.DescribeClusterServices(ctx, cluster)
+			return len(services), err
+		}, 1},
+		{"DescribeClusterTasks", func() (int, error) { tasks, err := backend.DescribeClusterTasks(ctx, cluster); return len(tasks), err }, 1},
+		{"DescribeClusterStoppedTasks", func() (int, error) {
+			tasks, err := backend.DescribeClusterStoppedTasks(ctx, cluster)
+			return len(tasks), err
+		}, 1},
+		{"DescribeContainerInstances", func() (int, error) {
+			instances, err := backend.DescribeContainerInstances(ctx, cluster)
+			return len(instances), err
+		}, 1},
+		{"GetTaskDefinitions", func() (int, error) {
+			taskDefs, err := backend.GetTaskDefinitions(ctx, append(tasks, tasks...))
+			return len(taskDefs), err
+		}, 1},
+	}
+
+	for _, test := range tests {
+		if got, err := test.describe(); err != nil || got != test.want {
+			t.Errorf("%s returned %d objects and %v, want %d", test.name, got, err, test.want)
+		}
+	}
+}
+
+func TestFixtureBackendInRegion(t *testing.T) {
+	tests := []struct {
+		region       string
+		wantClusters []string
+		wantFamilies []string
+	}{
+		{"", []string{"production", "staging"}, []string{"api", "web"}},
+		{"us-east-1", []string{"production"}, []string{"web"}},
+		{"us-west-2", []string{"staging"}, []string{"api"}},
+		{"eu-west-1", []string{}, []string{}},
+	}
+
+	for _, test := range tests {
+		backend := newTestFixtureBackend()
+		if test.region != "" {
+			backend = backend.InRegion(test.region)
+		}
+		if backend.Region() != test.region {
+			t.Errorf("InRegion(%q).Region() = %q", test.region, backend.Region())
+		}
+
+		clusters, err := backend.DescribeClusters(context.Background())
+		if err != nil {
+			t.Fatalf("InRegion(%q).DescribeClusters() failed: %v", test.region, err)
+		}
+		names := make([]string, 0)
+		for _, cluster := range clusters {
+			names = append(names, *cluster.ClusterName)
+		}
+		if strings.Join(names, ",") != strings.Join(test.wantClusters, ",") {
+			t.Errorf("InRegion(%q) clusters = %v, want %v", test.region, names, test.wantClusters)
+		}
+
+		families, err := backend.ListTaskDefinitionFamilies(context.Background())
+		if err != nil {
+			t.Fatalf("InRegion(%q).ListTaskDefinitionFamilies() failed: %v", test.region, err)
+		}
+		if strings.Join(families, ",") != strings.Join(test.wantFamilies, ",") {
+			t.Errorf("InRegion(%q) families = %v, want %v", test.region, families, test.wantFamilies)
+		}
+	}
+}
+
+func TestFixtureBackendListTaskDefinitionRevisions(t *testing.T) {
+	revisions, err := newTestFixtureBackend().ListTaskDefinitionRevisions(context.Background(), "web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(revisions) != 2 || !strings.HasSuffix(revisions[0], "web:42") || !strings.HasSuffix(revisions[1], "web:41") {
+		t.Errorf("revisions = %v, want web:42 then web:41", revisions)
+	}
+}
+
+func TestFixtureBackendUnknownCluster(t *testing.T) {
+	backend := newTestFixtureBackend()
+	unknown := &ecs.Cluster{ClusterArn: awssdk.String("arn:aws:ecs:us-east-1:123456789012:cluster/missing")}
+	if _, err := backend.DescribeClusterServices(context.Background(), unknown); err == nil ||
+		!strings.HasPrefix(err.Error(), "ClusterNotFoundException") {
+		t.Errorf("DescribeClusterServices() of an unknown cluster returned %v, want a ClusterNotFoundException", err)
+	}
+}
+
+func TestLoadFixtureBackend(t *testing.T) {
+	backend, err := LoadFixtureBackend("../../docs/fixtures/sample.json")
+	if err != nil {
+		t.Fatalf("LoadFixtureBackend() of the sample fixtures failed: %v", err)
+	}
+	if len(backend.Clusters) == 0 || len(backend.TaskDefinitions) == 0 {
+		t.Errorf("the sample fixtures have %d clusters and %d task definitions", len(backend.Clusters), len(backend.TaskDefinitions))
+	}
+
+	if _, err := LoadFixtureBackend("../../README.md"); err == nil || !strings.Contains(err.Error(), "README.md") {
+		t.Errorf("LoadFixtureBackend() of a file that isn't JSON returned %v, want an error naming it", err)
+	}
+}
+
+func TestFixtureBackendActionsCopyOnWrite(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name string
+
+		// Returns the object the action changes, as a caller would have loaded it before the action
+		before func(b *FixtureBackend, c *ecs.Cluster) interface{}
+
+		// Runs the action, returning an error if it failed
+		act func(b *FixtureBackend, c *ecs.Cluster) error
+
+		// Returns an error message if the object before the action or the reloaded object isn't what's expected
+		check func(before interface{}, b *FixtureBackend, c *ecs.Cluster) string
+	}{
+		{
+			name:   "UpdateServiceDesiredCount",
+			before: firstService,
+			act: func(b *FixtureBackend, c *ecs.Cluster) error {
+				_, err := b.UpdateServiceDesiredCount(ctx, c, testServiceArn, 5)
+				return err
+			},
+			check: func(before interface{}, b *FixtureBackend, c *ecs.Cluster) string {
+				if *before.(*ecs.Service).DesiredCount != 2 {
+					return "the original service was changed"
+				}
+				if after := firstService(b, c).(*ecs.Service); *after.DesiredCount != 5 {
+					return "the reloaded service doesn't have 5 desired tasks"
+				}
+				return ""
+			},
+		},
+		{
+			name:   "ForceNewDeployment",
+			before: firstService,
+			act: func(b *FixtureBackend, c *ecs.Cluster) error {
+				_, err := b.ForceNewDeployment(ctx, c, testServiceArn)
+				return err
+			},
+			check: func(before interface{}, b *FixtureBackend, c *ecs.Cluster) string {
+				original := before.(*ecs.Service)
+				if len(original.Deployments) != 1 || *original.Deployments[0].Status != "PRIMARY" {
+					return "the original service's deployments were changed"
+				}
+				after := firstService(b, c).(*ecs.Service)
+				if len(after.Deployments) != 2 || *after.Deployments[0].Status != "PRIMARY" || *after.Deployments[1].Status != "ACTIVE" {
+					return "the reloaded service doesn't have a new primary deployment and the old one active"
+				}
+				return ""
+			},
+		},
+		{
+			name:   "UpdateServiceTaskDefinition",
+			before: firstService,
+			act: func(b *FixtureBackend, c *ecs.Cluster) error {
+				_, err := b.UpdateServiceTaskDefinition(ctx, c, testServiceArn, "arn:aws:ecs:us-east-1:123456789012:task-definition/web:41")
+				return err
+			},
+			check: func(before interface{}, b *FixtureBackend, c *ecs.Cluster) string {
+				if *before.(*ecs.Service).TaskDefinition != testTaskDefArn {
+					return "the original service's task definition was changed"
+				}
+				if after := firstService(b, c).(*ecs.Service); !strings.HasSuffix(*after.TaskDefinition, "web:41") {
+					return "the reloaded service isn't deploying web:41"
+				}
+				return ""
+			},
+		},
+		{
+			name:   "ForceNewDeployment",
+			before: firstService,
+			act: func(b *FixtureBackend, c *ecs.Cluster) error {
+				_, err := b.ForceNewDeployment(ctx, c, testServiceArn)
+				return err
+			},
+			check: func(before interface{}, b *FixtureBackend, c *ecs.Cluster) string {
+				original := before.(*ecs.Service)
+				if len(original.Deployments) != 1 || *original.Deployments[0].Status != "PRIMARY" {
+					return "the original service's deployments were changed"
+				}
+				after := firstService(b, c).(*ecs.Service)
+				if len(after.Deployments) != 2 || *after.Deployments[0].Status != "PRIMARY" || *after.Deployments[1].Status != "ACTIVE" {
+					return "the reloaded service doesn't have a new primary deployment and the old one active"
+				}
+				return ""
+			},
+		},
+		{
+			name:   "UpdateServiceTaskDefinition",
+			before: firstService,
+			act: func(b *FixtureBackend, c *ecs.Cluster) error {
+				_, err := b.UpdateServiceTaskDefinition(ctx, c, testServiceArn, "arn:aws:ecs:us-east-1:123456789012:task-definition/web:41")
+				return err
+			},
+			check: func(before interface{}, b *FixtureBackend, c *ecs.Cluster) string {
+				if *before.(*ecs.Service).TaskDefinition != testTaskDefArn {
+					return "the original service's task definition was changed"
+				}
+				if after := firstService(b, c).(*ecs.Service); !strings.HasSuffix(*after.TaskDefinition, "web:41") {
+					return "the reloaded service isn't deploying web:41"
+				}
+				return ""
+			},
+		},
+		{
+			name: "StopTask",
+			before: func(b *FixtureBackend, c *ecs.Cluster) interface{} {
+				tasks, _ := b.DescribeClusterTasks(ctx, c)
+				return tasks[0]
+			},
+			act: func(b *FixtureBackend, c *ecs.Cluster) error {
+				_, err := b.StopTask(ctx, c, testTaskArn, "testing")
+				return err
+			},
+			check: func(before interface{}, b *FixtureBackend, c *ecs.Cluster) string {
+				if *before.(*ecs.Task).DesiredStatus != ecs.DesiredStatusRunning {
+					return "the original task was stopped"
+				}
+				if running, _ := b.DescribeClusterTasks(ctx, c); len(running) != 0 {
+					return "the task is still listed as running"
+				}
+				stopped, _ := b.DescribeClusterStoppedTasks(ctx, c)
+				if len(stopped) != 1 || *stopped[0].StoppedReason != "testing" || *stopped[0].StopCode != ecs.TaskStopCodeUserInitiated {
+					return "the task isn't listed as stopped by the user with its reason"
+				}
+				return ""
+			},
+		},
+		{
+			name:   "UpdateContainerInstancesState",
+			before: firstInstance,
+			act: func(b *FixtureBackend, c *ecs.Cluster) error {
+				_, failures, err := b.UpdateContainerInstancesState(ctx, c, []string{testInstanceArn}, ecs.ContainerInstanceStatusDraining)
+				if len(failures) != 0 {
+					t.Errorf("UpdateContainerInstancesState() failed for %v", failures)
+				}
+				return err
+			},
+			check: func(before interface{}, b *FixtureBackend, c *ecs.Cluster) string {
+				if *before.(*ecs.ContainerInstance).Status != ecs.ContainerInstanceStatusActive {
+					return "the original instance was changed"
+				}
+				if after := firstInstance(b, c).(*ecs.ContainerInstance); *after.Status != ecs.ContainerInstanceStatusDraining {
+					return "the reloaded instance isn't draining"
+				}
+				return ""
+			},
+		},
+		{
+			name:   "UpdateContainerAgent",
+			before: firstInstance,
+			act: func(b *FixtureBackend, c *ecs.Cluster) error {
+				_, err := b.UpdateContainerAgent(ctx, c, testInstanceArn)
+				return err
+			},
+			check: func(before interface{}, b *FixtureBackend, c *ecs.Cluster) string {
+				if before.(*ecs.ContainerInstance).AgentUpdateStatus != nil {
+					return "the original instance was changed"
+				}
+				if after := firstInstance(b, c).(*ecs.ContainerInstance); after.AgentUpdateStatus == nil {
+					return "the reloaded instance has no agent update"
+				}
+				return ""
+			},
+		},
+	}
+
+	for _, test := range tests {
+		backend := newTestFixtureBackend()
+		cluster := backend.Clusters[0].Cluster
+		before := test.before(backend, cluster)
+		if err := test.act(backend, cluster); err != nil {
+			t.Errorf("%s failed: %v", test.name, err)
+			continue
+		}
+		if problem := test.check(before, backend, cluster); problem != "" {
+			t.Errorf("%s: %s", test.name, problem)
+		}
+	}
+}
+
+func TestFixtureBackendActionsOnUnknownObjects(t *testing.T) {
+	ctx := context.Background()
+	backend := newTestFixtureBackend()
+	cluster := backend.Clusters[0].Cluster
+
+	if _, err := backend.UpdateServiceDesiredCount(ctx, cluster, "missing", 1); err == nil {
+		t.Error("UpdateServiceDesiredCount() of an unknown service succeeded")
+	}
+	if _, err := backend.StopTask(ctx, cluster, "missing", "testing"); err == nil {
+		t.Error("StopTask() of an unknown task succeeded")
+	}
+	if _, err := backend.UpdateContainerAgent(ctx, cluster, "missing"); err == nil {
+		t.Error("UpdateContainerAgent() of an unknown instance succeeded")
+	}
+	_, failures, err := backend.UpdateContainerInstancesState(ctx, cluster, []string{"missing"}, ecs.ContainerInstanceStatusDraining)
+	if err != nil || len(failures) != 1 || *failures[0].Arn != "missing" {
+		t.Errorf("UpdateContainerInstancesState() of an unknown instance returned %v, %v, want one failure", failures, err)
+	}
+}
+
+func TestFixtureBackendAgentUpdateProgress(t *testing.T) {
+	ctx := context.Background()
+	backend := newTestFixtureBackend()
+	cluster := backend.Clusters[0].Cluster
+
+	started, err := backend.UpdateContainerAgent(ctx, cluster, testInstanceArn)
+	if err != nil {
+		t.Fatalf("UpdateContainerAgent() error = %v", err)
+	}
+	if *started.AgentUpdateStatus != ecs.AgentUpdateStatusPending {
+		t.Errorf("started update is %s, want %s", *started.AgentUpdateStatus, ecs.AgentUpdateStatusPending)
+	}
+	if _, err := backend.UpdateContainerAgent(ctx, cluster, testInstanceArn); err == nil {
+		t.Error("UpdateContainerAgent() succeeded while an update was pending")
+	}
+
+	reloaded := make([]*ecs.ContainerInstance, 0)
+	for _, want := range []string{ecs.AgentUpdateStatusUpdating, ecs.AgentUpdateStatusUpdated, ecs.AgentUpdateStatusUpdated} {
+		instance := firstInstance(backend, cluster).(*ecs.ContainerInstance)
+		if *instance.AgentUpdateStatus != want {
+			t.Errorf("reloaded update is %s, want %s", *instance.AgentUpdateStatus, want)
+		}
+		reloaded = append(reloaded, instance)
+	}
+
+	// Each step replaces the instance, so those already returned keep their status
+	if *started.AgentUpdateStatus != ecs.AgentUpdateStatusPending {
+		t.Errorf("the started instance was changed to %s", *started.AgentUpdateStatus)
+	}
+	if *reloaded[0].AgentUpdateStatus != ecs.AgentUpdateStatusUpdating {
+		t.Errorf("the first reloaded instance was changed to %s", *reloaded[0].AgentUpdateStatus)
+	}
+
+	if _, err := backend.UpdateContainerAgent(ctx, cluster, testInstanceArn); err != nil {
+		t.Errorf("UpdateContainerAgent() after the update finished error = %v", err)
+	}
+}
+
+func firstService(b *FixtureBackend, c *ecs.Cluster) interface{} {
+	services, _ := b.DescribeClusterServices(context.Background(), c)
+	return services[0]
+}
+
+func firstInstance(b *FixtureBackend, c *ecs.Cluster) interface{} {
+	instances, _ := b.DescribeContainerInstances(context.Background(), c)
+	return instances[0]
+}
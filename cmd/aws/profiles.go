@@ -0,0 +1,57 @@
+package aws
+
+import (
+	"bufio"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws/defaults"
+)
+
+// Returns the names of the profiles in the shared AWS config and credentials files, sorted by name
+func ListProfiles() []string {
+	configFile := os.Getenv("AWS_CONFIG_FILE")
+	if configFile == "" {
+		configFile = defaults.SharedConfigFilename()
+	}
+	credentialsFile := os.Getenv("AWS_SHARED_CREDENTIALS_FILE")
+	if credentialsFile == "" {
+		credentialsFile = defaults.SharedCredentialsFilename()
+	}
+
+	profileSet := make(map[string]bool)
+	for _, profile := range readProfileSections(configFile) {
+		// The config file names its sections "profile <name>", except for the default profile
+		profileSet[strings.TrimSpace(strings.TrimPrefix(profile, "profile "))] = true
+	}
+	for _, profile := range readProfileSections(credentialsFile) {
+		profileSet[profile] = true
+	}
+
+	profiles := make([]string, 0, len(profileSet))
+	for profile := range profileSet {
+		profiles = append(profiles, profile)
+	}
+	sort.Strings(profiles)
+	return profiles
+}
+
+// Returns the section names in an ini file, or nothing if the file can't be read
+func readProfileSections(path string) []string {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	sections := make([]string, 0)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			sections = append(sections, strings.TrimSpace(line[1:len(line)-1]))
+		}
+	}
+	return sections
+}
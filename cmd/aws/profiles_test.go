@@ -0,0 +1,93 @@
+package aws
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Writes the contents to a file in the test's temporary directory, returning its path
+func writeTestFile(t *testing.T, name string, contents string) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := ioutil.WriteFile(path, []byte(contents), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+const testConfigFile = `[default]
+region = us-east-1
+
+# [profile retired]
+; [profile old]
+[profile dev]
+region = us-west-2
+role_arn = arn:aws:iam::123456789012:role/ecsview
+
+[ profile staging ]
+region = eu-west-1
+`
+
+const testCredentialsFile = `[default]
+aws_access_key_id = AKIAEXAMPLE
+
+[prod]
+aws_access_key_id = AKIAEXAMPLE
+# [retired]
+[dev]
+aws_access_key_id = AKIAEXAMPLE
+`
+
+func TestReadProfileSections(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+		want     []string
+	}{
+		{"config file", testConfigFile, []string{"default", "profile dev", "profile staging"}},
+		{"credentials file", testCredentialsFile, []string{"default", "prod", "dev"}},
+		{"no sections", "region = us-east-1\n", []string{}},
+	}
+	for _, test := range tests {
+		got := readProfileSections(writeTestFile(t, "config", test.contents))
+		if strings.Join(got, ",") != strings.Join(test.want, ",") {
+			t.Errorf("%s: readProfileSections() = %v, want %v", test.name, got, test.want)
+		}
+	}
+
+	if got := readProfileSections(filepath.Join(t.TempDir(), "missing")); got != nil {
+		t.Errorf("readProfileSections(missing file) = %v, want nil", got)
+	}
+}
+
+func TestListProfiles(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      string
+		credentials string
+		want        []string
+	}{
+		{"merges both files", testConfigFile, testCredentialsFile, []string{"default", "dev", "prod", "staging"}},
+		{"config file only", testConfigFile, "", []string{"default", "dev", "staging"}},
+		{"credentials file only", "", testCredentialsFile, []string{"default", "dev", "prod"}},
+		{"no files", "", "", []string{}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			setenv(t, "AWS_CONFIG_FILE", filepath.Join(dir, "missing-config"))
+			setenv(t, "AWS_SHARED_CREDENTIALS_FILE", filepath.Join(dir, "missing-credentials"))
+			if test.config != "" {
+				setenv(t, "AWS_CONFIG_FILE", writeTestFile(t, "config", test.config))
+			}
+			if test.credentials != "" {
+				setenv(t, "AWS_SHARED_CREDENTIALS_FILE", writeTestFile(t, "credentials", test.credentials))
+			}
+
+			if got := ListProfiles(); strings.Join(got, ",") != strings.Join(test.want, ",") {
+				t.Errorf("ListProfiles() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package aws
+
+import (
+	"sort"
+
+	"github.com/aws/aws-sdk-go/aws/arn"
+	"github.com/aws/aws-sdk-go/aws/endpoints"
+	"github.com/aws/aws-sdk-go/service/ecs"
+)
+
+// The --region value that selects every region where ECS is available
+const AllRegions = "all"
+
+// Returns the regions where ECS is available in the standard AWS partition, sorted by name
+func ListEcsRegions() []string {
+	regions := make([]string, 0)
+	if service, found := endpoints.AwsPartition().Services()[ecs.EndpointsID]; found {
+		for region := range service.Regions() {
+			regions = append(regions, region)
+		}
+	}
+	sort.Strings(regions)
+	return regions
+}
+
+// Expands the "all" region into every ECS region, leaving other region names as they are
+func ExpandRegions(regions []string) []string {
+	for _, region := range regions {
+		if region == AllRegions {
+			return ListEcsRegions()
+		}
+	}
+	return regions
+}
+
+// Returns the region in the given arn, or an empty string if it isn't a valid arn
+func RegionFromArn(resourceArn string) string {
+	parsed, err := arn.Parse(resourceArn)
+	if err != nil {
+		return ""
+	}
+	return parsed.Region
+}
+
+// Returns the account id in the given arn, or an empty string if it isn't a valid arn
+func AccountIdFromArn(resourceArn string) string {
+	parsed, err := arn.Parse(resourceArn)
+	if err != nil {
+		return ""
+	}
+	return parsed.AccountID
+}
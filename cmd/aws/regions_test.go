@@ -0,0 +1,54 @@
+package aws
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/thoas/go-funk"
+)
+
+func TestExpandRegions(t *testing.T) {
+	tests := []struct {
+		name    string
+		regions []string
+		want    []string
+	}{
+		{"no regions", nil, nil},
+		{"named regions", []string{"us-east-1", "eu-west-1"}, []string{"us-east-1", "eu-west-1"}},
+	}
+	for _, test := range tests {
+		if got := ExpandRegions(test.regions); strings.Join(got, ",") != strings.Join(test.want, ",") {
+			t.Errorf("%s: ExpandRegions(%v) = %v, want %v", test.name, test.regions, got, test.want)
+		}
+	}
+
+	// "all" anywhere in the list selects every ECS region, sorted
+	for _, regions := range [][]string{{AllRegions}, {"us-east-1", AllRegions}} {
+		got := ExpandRegions(regions)
+		if !sort.StringsAreSorted(got) || !funk.ContainsString(got, "us-east-1") || !funk.ContainsString(got, "eu-west-1") {
+			t.Errorf("ExpandRegions(%v) = %v, want every ECS region sorted", regions, got)
+		}
+		if funk.ContainsString(got, AllRegions) {
+			t.Errorf("ExpandRegions(%v) = %v, want %q expanded", regions, got, AllRegions)
+		}
+	}
+}
+
+func TestRegionFromArn(t *testing.T) {
+	tests := []struct {
+		arn  string
+		want string
+	}{
+		{testClusterArn, "us-east-1"},
+		{"arn:aws:ecs:eu-west-1:123456789012:task/production/0000000000000001", "eu-west-1"},
+		{"arn:aws:iam::123456789012:role/ecsview", ""},
+		{"production", ""},
+		{"", ""},
+	}
+	for _, test := range tests {
+		if got := RegionFromArn(test.arn); got != test.want {
+			t.Errorf("RegionFromArn(%q) = %q, want %q", test.arn, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package aws
+
+import (
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	awssdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/aws/session"
+)
+
+// The environment variable that overrides the AWS endpoint, if the endpoint isn't given on the command line
+const EndpointURLEnvVar = "ECSVIEW_ENDPOINT_URL"
+
+// How long assumed role credentials last before they're refreshed
+const assumeRoleDuration = time.Hour
+
+// Assumed role credentials are refreshed this long before they expire, so requests don't fail mid-load
+const assumeRoleExpiryWindow = time.Minute
+
+// Settings used to build the AWS session shared by every client in this package
+type SessionConfig struct {
+	// Sends all AWS requests to this URL instead of the AWS endpoints, eg "http://localhost:4566" for LocalStack
+	EndpointURL string
+
+	// The AWS region to use instead of the region in the shared config, eg "eu-west-1"
+	Region string
+
+	// The shared config profile to use instead of the AWS_PROFILE environment variable or the default profile
+	Profile string
+
+	// A role to assume with the profile's credentials, eg "arn:aws:iam::123456789012:role/ReadOnly"
+	RoleArn string
+
+	// The external id the role requires, if any
+	ExternalId string
+
+	// The serial number or arn of the MFA device the role requires, if any
+	MfaSerial string
+
+	// Returns an MFA token when a role needs one, for both RoleArn and profiles with mfa_serial in the shared config.
+	// Defaults to prompting on stdin.
+	TokenProvider func() (string, error)
+}
+
+// Returns the endpoint url from the config or, if unset, the ECSVIEW_ENDPOINT_URL environment variable
+func (c SessionConfig) GetEndpointURL() string {
+	if c.EndpointURL != "" {
+		return c.EndpointURL
+	}
+	return os.Getenv(EndpointURLEnvVar)
+}
+
+// Credentials shared by every session with the same profile and role, eg a session per region, so that each role is
+// assumed and each MFA token requested only once. The credentials refresh themselves when they expire.
+var sharedCredentials = make(map[string]*credentials.Credentials)
+var sharedCredentialsMutex sync.Mutex
+
+// Builds a session from the shared AWS config with the overrides in the given config
+func newSession(config SessionConfig) (*session.Session, error) {
+	awsConfig := awssdk.Config{}
+	if endpointURL := config.GetEndpointURL(); endpointURL != "" {
+		awsConfig.Endpoint = awssdk.String(endpointURL)
+	}
+	if config.Region != "" {
+		awsConfig.Region = awssdk.String(config.Region)
+	}
+
+	tokenProvider := config.TokenProvider
+	if tokenProvider == nil {
+		tokenProvider = stscreds.StdinTokenProvider
+	}
+
+	sess, err := session.NewSessionWithOptions(session.Options{
+		Config:                  awsConfig,
+		Profile:                 config.Profile,
+		SharedConfigState:       session.SharedConfigEnable,
+		AssumeRoleTokenProvider: tokenProvider,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sharedCredentialsMutex.Lock()
+	defer sharedCredentialsMutex.Unlock()
+
+	key := strings.Join([]string{config.GetEndpointURL(), config.Profile, config.RoleArn, config.ExternalId, config.MfaSerial}, "|")
+	creds, found := sharedCredentials[key]
+	if !found {
+		creds = sess.Config.Credentials
+		if config.RoleArn != "" {
+			creds = stscreds.NewCredentials(sess, config.RoleArn, func(p *stscreds.AssumeRoleProvider) {
+				p.Duration = assumeRoleDuration
+				p.ExpiryWindow = assumeRoleExpiryWindow
+				if config.ExternalId != "" {
+					p.ExternalID = awssdk.String(config.ExternalId)
+				}
+				if config.MfaSerial != "" {
+					p.SerialNumber = awssdk.String(config.MfaSerial)
+					p.TokenProvider = tokenProvider
+				}
+			})
+		}
+		sharedCredentials[key] = creds
+	}
+	sess.Config.Credentials = creds
+
+	return sess, nil
+}
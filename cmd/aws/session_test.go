@@ -0,0 +1,113 @@
+package aws
+
+import (
+	"os"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+)
+
+// Sets an environment variable for the rest of the test, restoring its previous value afterwards
+func setenv(t *testing.T, key string, value string) {
+	previous, found := os.LookupEnv(key)
+	if err := os.Setenv(key, value); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if found {
+			os.Setenv(key, previous)
+		} else {
+			os.Unsetenv(key)
+		}
+	})
+}
+
+func TestSessionConfigGetEndpointURL(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		env    string
+		want   string
+	}{
+		{"neither", "", "", ""},
+		{"config", "http://localhost:4566", "", "http://localhost:4566"},
+		{"environment", "", "http://localhost:4567", "http://localhost:4567"},
+		{"config wins over the environment", "http://localhost:4566", "http://localhost:4567", "http://localhost:4566"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setenv(t, EndpointURLEnvVar, tt.env)
+			if got := (SessionConfig{EndpointURL: tt.config}).GetEndpointURL(); got != tt.want {
+				t.Errorf("GetEndpointURL() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewSessionEndpoint(t *testing.T) {
+	setenv(t, EndpointURLEnvVar, "http://localhost:4567")
+
+	sess, err := newSession(SessionConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sess.Config.Endpoint == nil || *sess.Config.Endpoint != "http://localhost:4567" {
+		t.Errorf("session endpoint = %v, want the one from %s", sess.Config.Endpoint, EndpointURLEnvVar)
+	}
+
+	setenv(t, EndpointURLEnvVar, "")
+	if sess, err = newSession(SessionConfig{}); err != nil {
+		t.Fatal(err)
+	}
+	if sess.Config.Endpoint != nil {
+		t.Errorf("session endpoint = %v, want the AWS endpoints", *sess.Config.Endpoint)
+	}
+}
+
+func TestNewSessionSharesCredentials(t *testing.T) {
+	setenv(t, EndpointURLEnvVar, "")
+	setenv(t, "AWS_PROFILE", "")
+	setenv(t, "AWS_CONFIG_FILE", writeTestFile(t, "config", testConfigFile))
+	setenv(t, "AWS_SHARED_CREDENTIALS_FILE", writeTestFile(t, "credentials", testCredentialsFile))
+
+	previous := sharedCredentials
+	sharedCredentials = make(map[string]*credentials.Credentials)
+	defer func() { sharedCredentials = previous }()
+
+	role := "arn:aws:iam::123456789012:role/ecsview"
+	base := SessionConfig{Profile: "prod", RoleArn: role, ExternalId: "ecsview", MfaSerial: "arn:aws:iam::123456789012:mfa/ops"}
+	credentialsFor := func(config SessionConfig) *credentials.Credentials {
+		sess, err := newSession(config)
+		if err != nil {
+			t.Fatalf("newSession(%+v) failed: %v", config, err)
+		}
+		return sess.Config.Credentials
+	}
+	baseCreds := credentialsFor(base)
+
+	// Sessions for other regions reuse the credentials, so the role is assumed once
+	inRegion := base
+	inRegion.Region = "eu-west-1"
+	if credentialsFor(inRegion) != baseCreds {
+		t.Errorf("a session in another region has its own credentials, want them shared")
+	}
+
+	// Every other setting in the key gets its own credentials
+	tests := []struct {
+		name   string
+		change func(c *SessionConfig)
+	}{
+		{"endpoint", func(c *SessionConfig) { c.EndpointURL = "http://localhost:4566" }},
+		{"profile", func(c *SessionConfig) { c.Profile = "dev" }},
+		{"role", func(c *SessionConfig) { c.RoleArn = "arn:aws:iam::123456789012:role/other" }},
+		{"external id", func(c *SessionConfig) { c.ExternalId = "other" }},
+		{"mfa device", func(c *SessionConfig) { c.MfaSerial = "" }},
+	}
+	for _, test := range tests {
+		config := base
+		test.change(&config)
+		if credentialsFor(config) == baseCreds {
+			t.Errorf("a session with another %s shares the credentials, want its own", test.name)
+		}
+	}
+}
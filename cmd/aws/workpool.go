@@ -0,0 +1,161 @@
+package aws
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/request"
+)
+
+// The number of AWS requests a fan-out runs at once unless configured otherwise
+const DefaultConcurrency = 8
+
+const minThrottleDelay = 200 * time.Millisecond
+const maxThrottleDelay = 10 * time.Second
+const maxThrottleRetries = 8
+
+// Limits how many AWS requests a fan-out runs at once, and slows down every fan-out sharing the pool when AWS
+// throttles a request. The delay between requests doubles on each throttling error and halves on each success.
+type WorkPool struct {
+	concurrency int
+
+	mutex sync.Mutex
+	delay time.Duration
+}
+
+// Returns a pool that runs at most concurrency requests at once in each fan-out
+func NewWorkPool(concurrency int) *WorkPool {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	return &WorkPool{concurrency: concurrency}
+}
+
+// Calls fn for each index in [0, n) on the pool's workers, returning the first error. Once an item fails or the
+// context is cancelled the remaining items are skipped.
+func (p *WorkPool) Run(ctx context.Context, n int, fn func(ctx context.Context, i int) error) error {
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var firstErr error
+	var errOnce sync.Once
+	p.run(runCtx, n, fn, func(err error) {
+		errOnce.Do(func() {
+			firstErr = err
+			cancel()
+		})
+	})
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return firstErr
+}
+
+// Calls fn for each index in [0, n) on the pool's workers, returning each item's error. Unlike Run, an item that
+// fails, even after AWS throttled it for every retry, doesn't stop the rest.
+func (p *WorkPool) RunEach(ctx context.Context, n int, fn func(ctx context.Context, i int) error) []error {
+	return p.run(ctx, n, fn, func(err error) {})
+}
+
+// Calls fn for each index on the pool's workers until the context is cancelled, passing each error to failed
+func (p *WorkPool) run(ctx context.Context, n int, fn func(ctx context.Context, i int) error, failed func(err error)) []error {
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+
+	workers := p.concurrency
+	if n < workers {
+		workers = n
+	}
+
+	items := make(chan int)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range items {
+				i := i
+				errs[i] = p.call(ctx, func() error { return fn(ctx, i) })
+				if errs[i] != nil {
+					failed(errs[i])
+				}
+			}
+		}()
+	}
+
+feed:
+	for i := 0; i < n; i++ {
+		select {
+		case items <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(items)
+	wg.Wait()
+
+	return errs
+}
+
+// Calls fn after waiting out the pool's delay, retrying it with a longer delay while AWS throttles it
+func (p *WorkPool) call(ctx context.Context, fn func() error) error {
+	for attempt := 0; ; attempt++ {
+		if err := sleepWithContext(ctx, p.currentDelay()); err != nil {
+			return err
+		}
+
+		err := fn()
+		if err == nil {
+			p.speedUp()
+			return nil
+		}
+		if !request.IsErrorThrottle(err) || attempt == maxThrottleRetries {
+			return err
+		}
+		p.slowDown()
+	}
+}
+
+func (p *WorkPool) currentDelay() time.Duration {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.delay
+}
+
+// Doubles the delay between requests, up to the maximum
+func (p *WorkPool) slowDown() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.delay *= 2
+	if p.delay < minThrottleDelay {
+		p.delay = minThrottleDelay
+	}
+	if p.delay > maxThrottleDelay {
+		p.delay = maxThrottleDelay
+	}
+}
+
+// Halves the delay between requests, dropping it entirely once it's below the minimum
+func (p *WorkPool) speedUp() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.delay /= 2
+	if p.delay < minThrottleDelay {
+		p.delay = 0
+	}
+}
+
+func sleepWithContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
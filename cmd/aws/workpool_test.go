@@ -0,0 +1,225 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+)
+
+// Returns an error the SDK treats as AWS throttling the request
+func newThrottleError() error {
+	return awserr.New("ThrottlingException", "Rate exceeded", nil)
+}
+
+func TestWorkPoolRunConcurrencyBound(t *testing.T) {
+	tests := []struct {
+		name        string
+		concurrency int
+		n           int
+		wantMax     int32
+	}{
+		{"fewer items than workers", 8, 3, 3},
+		{"more items than workers", 4, 20, 4},
+		{"concurrency below one runs serially", 0, 5, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var running, maxRunning, calls int32
+			err := NewWorkPool(tt.concurrency).Run(context.Background(), tt.n, func(ctx context.Context, i int) error {
+				now := atomic.AddInt32(&running, 1)
+				for {
+					seen := atomic.LoadInt32(&maxRunning)
+					if now <= seen || atomic.CompareAndSwapInt32(&maxRunning, seen, now) {
+						break
+					}
+				}
+				time.Sleep(20 * time.Millisecond)
+				atomic.AddInt32(&running, -1)
+				atomic.AddInt32(&calls, 1)
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			if calls != int32(tt.n) {
+				t.Errorf("fn called %d times, want %d", calls, tt.n)
+			}
+			if maxRunning != tt.wantMax {
+				t.Errorf("at most %d ran at once, want %d", maxRunning, tt.wantMax)
+			}
+		})
+	}
+}
+
+func TestWorkPoolRunStopsEarly(t *testing.T) {
+	failure := errors.New("access denied")
+	tests := []struct {
+		name    string
+		fn      func(cancel context.CancelFunc, i int) error
+		wantErr error
+	}{
+		{
+			name: "cancelled context",
+			fn: func(cancel context.CancelFunc, i int) error {
+				if i == 2 {
+					cancel()
+				}
+				return nil
+			},
+			wantErr: context.Canceled,
+		},
+		{
+			name: "failed item",
+			fn: func(cancel context.CancelFunc, i int) error {
+				if i == 2 {
+					return failure
+				}
+				return nil
+			},
+			wantErr: failure,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			var calls int32
+			err := NewWorkPool(1).Run(ctx, 100, func(ctx context.Context, i int) error {
+				atomic.AddInt32(&calls, 1)
+				return tt.fn(cancel, i)
+			})
+			if err != tt.wantErr {
+				t.Errorf("Run() error = %v, want %v", err, tt.wantErr)
+			}
+			if calls >= 100 {
+				t.Errorf("fn called %d times, want the remaining items skipped", calls)
+			}
+		})
+	}
+}
+
+func TestWorkPoolRunEachKeepsGoing(t *testing.T) {
+	failure := errors.New("access denied")
+	var calls int32
+	errs := NewWorkPool(2).RunEach(context.Background(), 5, func(ctx context.Context, i int) error {
+		atomic.AddInt32(&calls, 1)
+		if i%2 == 1 {
+			return failure
+		}
+		return nil
+	})
+	if calls != 5 {
+		t.Errorf("fn called %d times, want every item run", calls)
+	}
+	want := []error{nil, failure, nil, failure, nil}
+	if len(errs) != len(want) {
+		t.Fatalf("RunEach() = %v, want %v", errs, want)
+	}
+	for i := range want {
+		if errs[i] != want[i] {
+			t.Errorf("RunEach()[%d] = %v, want %v", i, errs[i], want[i])
+		}
+	}
+}
+
+func TestWorkPoolRunRetriesThrottling(t *testing.T) {
+	tests := []struct {
+		name      string
+		errs      []error
+		wantErr   bool
+		wantCalls int
+	}{
+		{"succeeds first time", nil, false, 1},
+		{"succeeds after throttling", []error{newThrottleError(), newThrottleError()}, false, 3},
+		{"doesn't retry other errors", []error{errors.New("access denied")}, true, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			err := NewWorkPool(1).Run(context.Background(), 1, func(ctx context.Context, i int) error {
+				calls++
+				if calls <= len(tt.errs) {
+					return tt.errs[calls-1]
+				}
+				return nil
+			})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Run() error = %v, want error %v", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("fn called %d times, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestWorkPoolDelay(t *testing.T) {
+	tests := []struct {
+		name  string
+		steps []func(p *WorkPool)
+		want  time.Duration
+	}{
+		{"starts without a delay", nil, 0},
+		{"first throttle uses the minimum", []func(p *WorkPool){(*WorkPool).slowDown}, minThrottleDelay},
+		{
+			"each throttle doubles it",
+			[]func(p *WorkPool){(*WorkPool).slowDown, (*WorkPool).slowDown, (*WorkPool).slowDown},
+			4 * minThrottleDelay,
+		},
+		{
+			"each success halves it",
+			[]func(p *WorkPool){(*WorkPool).slowDown, (*WorkPool).slowDown, (*WorkPool).slowDown, (*WorkPool).speedUp},
+			2 * minThrottleDelay,
+		},
+		{
+			"drops below the minimum to none",
+			[]func(p *WorkPool){(*WorkPool).slowDown, (*WorkPool).slowDown, (*WorkPool).speedUp, (*WorkPool).speedUp},
+			0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewWorkPool(1)
+			for _, step := range tt.steps {
+				step(p)
+			}
+			if got := p.currentDelay(); got != tt.want {
+				t.Errorf("delay = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("is capped at the maximum", func(t *testing.T) {
+		p := NewWorkPool(1)
+		for i := 0; i < 20; i++ {
+			p.slowDown()
+		}
+		if got := p.currentDelay(); got != maxThrottleDelay {
+			t.Errorf("delay = %v, want %v", got, maxThrottleDelay)
+		}
+	})
+
+	t.Run("throttled run leaves it doubled then halved", func(t *testing.T) {
+		p := NewWorkPool(1)
+		calls := 0
+		err := p.Run(context.Background(), 1, func(ctx context.Context, i int) error {
+			calls++
+			if calls <= 2 {
+				return newThrottleError()
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Run() error = %v", err)
+		}
+		// Two throttles double it to twice the minimum, then the success halves it
+		if got := p.currentDelay(); got != minThrottleDelay {
+			t.Errorf("delay = %v, want %v", got, minThrottleDelay)
+		}
+	})
+}
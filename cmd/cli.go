@@ -0,0 +1,246 @@
+package cmd
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"reflect"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/thoas/go-funk"
+
+	"github.com/swartzrock/ecsview/cmd/aws"
+	"github.com/swartzrock/ecsview/cmd/ecsview"
+	"github.com/swartzrock/ecsview/cmd/pages"
+	"github.com/swartzrock/ecsview/cmd/utils"
+)
+
+// The formats a command can print its output in
+var OutputFormats = []string{"table", "json", "yaml", "csv"}
+
+// Describes the commands that print ECS data instead of starting the UI, for the usage message
+var CommandUsage = [][2]string{
+	{"clusters", "list the clusters"},
+	{"services <cluster>", "list the services in a cluster"},
+	{"tasks <cluster>", "list the running tasks in a cluster"},
+	{"instances <cluster>", "list the container instances in a cluster"},
+	{"watch services <cluster>", "print changes to a cluster's services as they happen, see -interval"},
+	{"serve", "serve Prometheus metrics for every cluster, see -metrics-addr and -interval"},
+}
+
+// Runs the command in the args, eg "services production", printing its output to stdout instead of starting the UI
+func RunCommand(options Options, args []string) error {
+	if !funk.ContainsString(OutputFormats, options.Output) {
+		return fmt.Errorf("unknown output format %q, choose from: %s", options.Output, strings.Join(OutputFormats, ", "))
+	}
+
+	command, args := args[0], args[1:]
+	usage, found := findCommandUsage(command)
+	if !found {
+		return fmt.Errorf("unknown command %q, run ecsview -h for the commands", command)
+	}
+	if len(args) != len(strings.Fields(usage))-1 {
+		return fmt.Errorf("usage: ecsview %s", usage)
+	}
+
+	if err := initCommand(options); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	if command == "clusters" {
+		return printClusters(ctx, os.Stdout, options.Output)
+	}
+
+	if command == "watch" {
+		return runWatch(ctx, options, args[0], args[1])
+	}
+	if command == "serve" {
+		return runServe(ctx, options)
+	}
+
+	cluster, err := findCluster(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	ecsData, loadErr := ecsview.GetClusterData(ctx, cluster, nil)
+	if ecsData == nil {
+		return loadErr
+	}
+
+	var objects interface{}
+	switch command {
+	case "services":
+		objects = ecsData.Services
+	case "tasks":
+		objects = ecsData.Tasks
+	case "instances":
+		objects = ecsData.Containers
+	}
+	titles, rows := pages.PageText(command, userConfig, ecsData)
+	if err := printOutput(os.Stdout, options.Output, titles, rows, objects); err != nil {
+		return err
+	}
+
+	// Report data that failed to load after printing the data that did
+	return loadErr
+}
+
+// Returns the usage of the command with the given name, eg "services <cluster>"
+func findCommandUsage(command string) (string, bool) {
+	for _, usage := range CommandUsage {
+		if strings.Fields(usage[0])[0] == command {
+			return usage[0], true
+		}
+	}
+	return "", false
+}
+
+// Loads the config and sets up the backends for a command. MFA tokens are read from stdin rather than a popup.
+func initCommand(options Options) error {
+	cfg, err := loadConfig(options.ConfigFile)
+	if err != nil {
+		return fmt.Errorf("unable to read the config file: %w", err)
+	}
+	userConfig = cfg
+
+	options.Session.TokenProvider = stscreds.StdinTokenProvider
+	workPool = aws.NewWorkPool(options.Concurrency)
+	backends, err := newBackends(options, workPool, options.Profiles, aws.ExpandRegions(options.Regions))
+	if err != nil {
+		return fmt.Errorf("unable to initialize the ECS backend: %w", err)
+	}
+	ecsview.SetBackends(backends)
+	ecsview.SetWorkPool(workPool)
+	ecsview.SetCacheTTL(options.CacheTTL)
+	ecsview.SetAgentVersionCheck(checksAgentVersion(options))
+	return nil
+}
+
+// Returns a context that's cancelled on Ctrl-C or a kill, so a long-running command can stop cleanly
+func withInterrupt(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		defer signal.Stop(signals)
+		select {
+		case <-signals:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
+
+// Returns the cluster with the given name or arn. A name must belong to only one of the profiles and regions.
+func findCluster(ctx context.Context, nameOrArn string) (*aws.EcsCluster, error) {
+	clusters, err := ecsview.GetClusters(ctx, nil)
+	matches := funk.Filter(clusters, func(c *aws.EcsCluster) bool {
+		return *c.ClusterName == nameOrArn || *c.ClusterArn == nameOrArn
+	}).([]*aws.EcsCluster)
+
+	switch {
+	case len(matches) == 1:
+		return matches[0], nil
+	case len(matches) > 1:
+		arns := funk.Map(matches, func(c *aws.EcsCluster) string { return *c.ClusterArn }).([]string)
+		return nil, fmt.Errorf("there are %d clusters named %s, use one of their arns: %s", len(matches), nameOrArn, strings.Join(arns, ", "))
+	case err != nil:
+		return nil, err
+	}
+	return nil, fmt.Errorf("cluster %s not found", nameOrArn)
+}
+
+// Prints the clusters in every profile and region, with their total reserved CPU and memory
+func printClusters(ctx context.Context, w io.Writer, format string) error {
+	clusters, err := ecsview.GetClusters(ctx, nil)
+	if len(clusters) == 0 && err != nil {
+		return err
+	}
+
+	titles := []string{"Name", "Account", "Region", "Status", "Type", "Instances", "Services", "Tasks", "CPU", "Memory"}
+	rows := funk.Map(clusters, func(cluster *aws.EcsCluster) []string {
+		usage := aws.EcsContainerStats{}
+		funk.ForEach(ecsview.GetClusterContainers(cluster), func(c *aws.EcsContainer) { usage.Add(c.GetStats()) })
+
+		account := cluster.AccountId
+		if cluster.Profile != "" {
+			account = fmt.Sprintf("%s (%s)", account, cluster.Profile)
+		}
+
+		return []string{
+			*cluster.ClusterName,
+			account,
+			cluster.Region,
+			utils.LowerTitle(*cluster.Status),
+			cluster.GetClusterType(),
+			utils.I64ToString(*cluster.RegisteredContainerInstancesCount),
+			utils.I64ToString(*cluster.ActiveServicesCount),
+			utils.I64ToString(*cluster.RunningTasksCount),
+			formatPercent(usage.CpuUsed, usage.CpuTotal),
+			formatPercent(usage.MemoryUsed, usage.MemoryTotal),
+		}
+	}).([][]string)
+
+	return printOutput(w, format, titles, rows, clusters)
+}
+
+// Returns the portion of the total as a percentage, eg "35%", or "n/a" if the total is zero
+func formatPercent(portion int64, total int64) string {
+	if total == 0 {
+		return "n/a"
+	}
+	return fmt.Sprintf("%.0f%%", float64(portion)/float64(total)*100)
+}
+
+// Prints the rows under the titles as an aligned table or CSV, or prints the objects the rows describe, eg the ECS
+// services, as JSON or YAML with the AWS SDK field names
+func printOutput(w io.Writer, format string, titles []string, rows [][]string, objects interface{}) error {
+
+	// Print no objects as an empty list rather than null
+	if value := reflect.ValueOf(objects); value.Kind() == reflect.Slice && value.IsNil() {
+		objects = []interface{}{}
+	}
+
+	switch format {
+	case "json":
+		text, err := utils.PrettyJSON(objects)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, text)
+		return err
+
+	case "yaml":
+		text, err := utils.PrettyYAML(objects)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprint(w, text)
+		return err
+
+	case "csv":
+		csvWriter := csv.NewWriter(w)
+		if err := csvWriter.Write(titles); err != nil {
+			return err
+		}
+		if err := csvWriter.WriteAll(rows); err != nil {
+			return err
+		}
+		return csvWriter.Error()
+	}
+
+	tableWriter := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tableWriter, strings.ToUpper(strings.Join(titles, "\t")))
+	for _, row := range rows {
+		fmt.Fprintln(tableWriter, strings.Join(row, "\t"))
+	}
+	return tableWriter.Flush()
+}
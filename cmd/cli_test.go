@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	awssdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ecs"
+
+	"github.com/swartzrock/ecsview/cmd/aws"
+	"github.com/swartzrock/ecsview/cmd/ecsview"
+)
+
+func TestPrintOutput(t *testing.T) {
+	titles := []string{"Name", "Status"}
+	clusters := []*ecs.Cluster{{ClusterName: awssdk.String("production"), Status: awssdk.String("ACTIVE")}}
+	rows := [][]string{{"production", "ACTIVE"}}
+
+	tests := []struct {
+		name    string
+		format  string
+		rows    [][]string
+		objects interface{}
+		want    string
+	}{
+		{"empty table", "table", nil, []*ecs.Cluster(nil), "NAME  STATUS\n"},
+		{"empty csv", "csv", nil, []*ecs.Cluster(nil), "Name,Status\n"},
+		{"empty json", "json", nil, []*ecs.Cluster(nil), "[]\n"},
+		{"empty yaml", "yaml", nil, []*ecs.Cluster(nil), "[]\n"},
+		{"empty non-nil slice", "json", nil, []*ecs.Cluster{}, "[]\n"},
+		{"table", "table", rows, clusters, "NAME        STATUS\nproduction  ACTIVE\n"},
+		{"csv", "csv", rows, clusters, "Name,Status\nproduction,ACTIVE\n"},
+		{"json", "json", rows, clusters, "[\n  {\n    \"ClusterName\": \"production\",\n    \"Status\": \"ACTIVE\"\n  }\n]\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			if err := printOutput(&out, tt.format, titles, tt.rows, tt.objects); err != nil {
+				t.Fatalf("printOutput() error = %v", err)
+			}
+			if out.String() != tt.want {
+				t.Errorf("printOutput() = %q, want %q", out.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestPrintClusters(t *testing.T) {
+	ecsview.SetBackend(&aws.FixtureBackend{Clusters: []*aws.FixtureCluster{{
+		Cluster: &ecs.Cluster{
+			ClusterArn:                        awssdk.String("arn:aws:ecs:us-east-1:123456789012:cluster/production"),
+			ClusterName:                       awssdk.String("production"),
+			Status:                            awssdk.String("ACTIVE"),
+			RegisteredContainerInstancesCount: awssdk.Int64(0),
+			ActiveServicesCount:               awssdk.Int64(2),
+			RunningTasksCount:                 awssdk.Int64(3),
+		},
+	}}})
+	defer ecsview.SetBackend(nil)
+
+	var out bytes.Buffer
+	if err := printClusters(context.Background(), &out, "csv"); err != nil {
+		t.Fatalf("printClusters() error = %v", err)
+	}
+	want := "Name,Account,Region,Status,Type,Instances,Services,Tasks,CPU,Memory\n" +
+		"production,123456789012,us-east-1,Active,EC2,0,2,3,n/a,n/a\n"
+	if out.String() != want {
+		t.Errorf("printClusters() = %q, want %q", out.String(), want)
+	}
+}
@@ -0,0 +1,120 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// The user's ecsview settings, read from a YAML file, eg
+//
+//	pages:
+//	  tasks:
+//	    columns:
+//	      - TaskDef
+//	      - name: Images
+//	        item-width: 30
+//	      - name: Tag
+//	        tag: team
+type Config struct {
+	// The columns of each cluster details page, keyed by the page name in lower case, eg "services" or "stopped-tasks"
+	Pages map[string]PageConfig `yaml:"pages"`
+}
+
+// The settings for one cluster details page
+type PageConfig struct {
+	// The columns to show after the row number, in order. The page's default columns are shown if none are given.
+	Columns []Column `yaml:"columns"`
+}
+
+// A column to show on a page, with overrides for its default layout
+type Column struct {
+	// The column's name, which is its default title, eg "Images" or "Launch Type"
+	Name string `yaml:"name"`
+
+	// The title shown in the column's header instead of its name
+	Title string `yaml:"title,omitempty"`
+
+	// The tag key shown in a "Tag" column, eg "team"
+	Tag string `yaml:"tag,omitempty"`
+
+	// The most characters shown in the column, or 0 for no limit. Longer text is truncated with "…".
+	Width *int `yaml:"width,omitempty"`
+
+	// The most characters shown for each item in a column that lists several, eg each image, or 0 for no limit
+	ItemWidth *int `yaml:"item-width,omitempty"`
+
+	// Which end of long text is cut, "right" to keep the start or "left" to keep the end, eg of an arn
+	Truncate string `yaml:"truncate,omitempty"`
+
+	// The column's share of the table's spare width, relative to the other columns
+	Expansion *int `yaml:"expansion,omitempty"`
+
+	// The text alignment, "left", "center" or "right"
+	Align string `yaml:"align,omitempty"`
+}
+
+// Reads a column given as just its name, or with its settings
+func (c *Column) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var name string
+	if err := unmarshal(&name); err == nil {
+		*c = Column{Name: name}
+		return nil
+	}
+
+	// Unmarshal into a type without this method, so it doesn't recurse
+	type columnSettings Column
+	settings := columnSettings{}
+	if err := unmarshal(&settings); err != nil {
+		return err
+	}
+	*c = Column(settings)
+	return nil
+}
+
+// Returns the configured columns of the page with the given name, or nil to show the page's default columns
+func (c *Config) Columns(page string) []Column {
+	if c == nil {
+		return nil
+	}
+	return c.Pages[strings.ToLower(page)].Columns
+}
+
+// Returns the path of the user's config file, $XDG_CONFIG_HOME/ecsview/config.yaml or ~/.config/ecsview/config.yaml
+func DefaultPath() string {
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		configHome = filepath.Join(home, ".config")
+	}
+	return filepath.Join(configHome, "ecsview", "config.yaml")
+}
+
+// Reads the config file at the given path. A missing file is an empty config, unless required is true.
+func Load(path string, required bool) (*Config, error) {
+	config := &Config{}
+	if path == "" {
+		return config, nil
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) && !required {
+		return config, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Reject unknown settings, so a misspelt one isn't silently ignored
+	if err := yaml.UnmarshalStrict(data, config); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return config, nil
+}
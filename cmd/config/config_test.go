@@ -0,0 +1,128 @@
+package config
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Returns the path of a config file in a temporary directory holding the text
+func writeConfig(t *testing.T, text string) string {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := ioutil.WriteFile(path, []byte(text), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestColumnUnmarshalYAML(t *testing.T) {
+	width := 30
+	tests := []struct {
+		name string
+		yaml string
+		want Column
+	}{
+		{"name", "TaskDef", Column{Name: "TaskDef"}},
+		{"quoted name", `"Launch Type"`, Column{Name: "Launch Type"}},
+		{"map with a name", "name: Images", Column{Name: "Images"}},
+		{"map with settings", "{name: Images, item-width: 30, truncate: left}",
+			Column{Name: "Images", ItemWidth: &width, Truncate: "left"}},
+		{"tag column", "{name: Tag, tag: team, title: Team}", Column{Name: "Tag", Tag: "team", Title: "Team"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var column Column
+			if err := yaml.UnmarshalStrict([]byte(tt.yaml), &column); err != nil {
+				t.Fatalf("UnmarshalStrict() error = %v", err)
+			}
+			if !reflect.DeepEqual(column, tt.want) {
+				t.Errorf("column = %+v, want %+v", column, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		yaml    string
+		page    string
+		want    []string
+		wantErr string
+	}{
+		{
+			name: "names and maps",
+			yaml: "pages:\n  tasks:\n    columns:\n      - TaskDef\n      - name: Images\n        item-width: 30\n",
+			page: "Tasks",
+			want: []string{"TaskDef", "Images"},
+		},
+		{
+			name: "page without columns",
+			yaml: "pages:\n  tasks:\n    columns:\n      - TaskDef\n",
+			page: "services",
+			want: nil,
+		},
+		{
+			name:    "misspelt column setting",
+			yaml:    "pages:\n  tasks:\n    columns:\n      - name: Images\n        itemwidth: 30\n",
+			wantErr: "itemwidth",
+		},
+		{
+			name:    "misspelt top-level key",
+			yaml:    "page:\n  tasks:\n    columns:\n      - TaskDef\n",
+			wantErr: "page",
+		},
+		{
+			name:    "column that isn't a name or a map",
+			yaml:    "pages:\n  tasks:\n    columns:\n      - [TaskDef]\n",
+			wantErr: "cannot unmarshal",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeConfig(t, tt.yaml)
+			config, err := Load(path, true)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) || !strings.Contains(err.Error(), path) {
+					t.Fatalf("Load() error = %v, want one naming %q and the file", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+
+			var names []string
+			for _, column := range config.Columns(tt.page) {
+				names = append(names, column.Name)
+			}
+			if !reflect.DeepEqual(names, tt.want) {
+				t.Errorf("Columns(%q) = %q, want %q", tt.page, names, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadMissingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing.yaml")
+
+	config, err := Load(path, false)
+	if err != nil || config == nil || config.Columns("tasks") != nil {
+		t.Errorf("Load(optional) = %v, %v, want an empty config", config, err)
+	}
+	if _, err := Load(path, true); err == nil {
+		t.Error("Load(required) succeeded, want an error")
+	}
+	if config, err := Load("", true); err != nil || config == nil {
+		t.Errorf("Load(\"\") = %v, %v, want an empty config", config, err)
+	}
+
+	var none *Config
+	if none.Columns("tasks") != nil {
+		t.Error("nil config has columns")
+	}
+}
@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+
+	"github.com/swartzrock/ecsview/cmd/aws"
+	"github.com/swartzrock/ecsview/cmd/ecsview"
+	"github.com/swartzrock/ecsview/cmd/pages"
+)
+
+const detailsPageName = "details"
+
+// A detail view shown over the main view, and the cluster its row belongs to
+type openDetails struct {
+	cluster       *aws.EcsCluster
+	details       *pages.DetailsView
+	previousFocus tview.Primitive
+
+	// Cancelled when the view closes, stopping its background loads
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// The detail view being shown, if any. Only accessed from the UI goroutine.
+var currentDetails *openDetails
+
+// Show the detail view of a row in a cluster details page, if the page has one
+func showDetails(page *pages.ClusterDetailsPage, row int) {
+	if page.Details == nil || row < 1 || row >= page.GetTable().GetRowCount() {
+		return
+	}
+	id, ok := page.GetTable().GetCell(row, 0).GetReference().(string)
+	if !ok {
+		return
+	}
+	cluster := getCurrentlySelectedCluster()
+	if cluster == nil {
+		return
+	}
+	ecsData, _ := ecsview.GetCachedClusterData(cluster)
+	if ecsData == nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	currentDetails = &openDetails{cluster: cluster, previousFocus: tviewApp.GetFocus(), ctx: ctx, cancel: cancel}
+	details := page.Details(id)
+	currentDetails.details = details
+	if !details.Render(ecsData) {
+		cancel()
+		currentDetails = nil
+		return
+	}
+
+	// Leave the bottom line empty so the footer shows through
+	layout := tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(details.View, 0, 1, true).
+		AddItem(nil, 1, 0, false)
+	layout.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEscape {
+			closeDetails()
+			return nil
+		}
+		if event.Key() == tcell.KeyRune && (event.Rune() == 'r' || event.Rune() == 'R') {
+			refreshCurrentCluster()
+			return nil
+		}
+		return event
+	})
+
+	rootPages.AddPage(detailsPageName, layout, true, true)
+	writeDetailsFooterText()
+}
+
+// Close the detail view, returning to the page it was opened from
+func closeDetails() {
+	if currentDetails == nil {
+		return
+	}
+	previousFocus := currentDetails.previousFocus
+	currentDetails.cancel()
+	currentDetails = nil
+	rootPages.RemovePage(detailsPageName)
+	updateCommandFooterBar()
+	if previousFocus != nil {
+		tviewApp.SetFocus(previousFocus)
+	}
+}
+
+// Render the detail view again from its cluster's latest data, closing it if its row is gone
+func renderDetails(ecsData *ecsview.ClusterData) {
+	if currentDetails == nil || currentDetails.cluster != ecsData.Cluster {
+		return
+	}
+	if !currentDetails.details.Render(ecsData) {
+		closeDetails()
+	}
+}
+
+// Run load on a background goroutine for the open detail view, then run done on the UI goroutine if the view is
+// still open
+func runDetailsLoad(load func(ctx context.Context), done func()) {
+	if currentDetails == nil {
+		return
+	}
+	ctx := currentDetails.ctx
+	go func() {
+		load(ctx)
+		tviewApp.QueueUpdateDraw(func() {
+			if ctx.Err() == nil {
+				done()
+			}
+		})
+	}()
+}
+
+// Write the detail view's commands into the command footer bar
+func writeDetailsFooterText() {
+	commandFooterBar.Clear()
+	fmt.Fprint(commandFooterBar, `[white::b]←/→[darkcyan::-] Tabs `)
+	if currentDetails != nil && currentDetails.details.Commands != "" {
+		fmt.Fprintf(commandFooterBar, `%s `, currentDetails.details.Commands)
+	}
+	fmt.Fprintf(commandFooterBar, `%c [white::b]R[darkcyan::-] Refresh-Data [white::b]Esc[darkcyan::-] Back`, tcell.RuneVLine)
+}
@@ -0,0 +1,116 @@
+package ecsview
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+
+	awssdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ecs"
+	"github.com/thoas/go-funk"
+
+	"github.com/swartzrock/ecsview/cmd/aws"
+	"github.com/swartzrock/ecsview/cmd/utils"
+)
+
+// Sets the number of tasks the service keeps running, returning the updated service. The cluster's data isn't
+// refreshed, so call RefreshClusterData to see the service's tasks start or stop.
+func ScaleService(ctx context.Context, cluster *aws.EcsCluster, service *ecs.Service, desiredCount int64) (*ecs.Service, error) {
+	backend, err := actionBackendFor(cluster)
+	if err != nil {
+		return nil, err
+	}
+	return backend.UpdateServiceDesiredCount(ctx, cluster.Cluster, *service.ServiceArn, desiredCount)
+}
+
+// Starts a new deployment of the service with its current task definition, eg to pull a new image with the same tag,
+// returning the updated service
+func ForceNewDeployment(ctx context.Context, cluster *aws.EcsCluster, service *ecs.Service) (*ecs.Service, error) {
+	backend, err := actionBackendFor(cluster)
+	if err != nil {
+		return nil, err
+	}
+	return backend.ForceNewDeployment(ctx, cluster.Cluster, *service.ServiceArn)
+}
+
+// Deploys the task definition to the service, eg to roll back to a previous revision, returning the updated service
+func DeployTaskDefinition(ctx context.Context, cluster *aws.EcsCluster, service *ecs.Service, taskDefinitionArn string) (*ecs.Service, error) {
+	backend, err := actionBackendFor(cluster)
+	if err != nil {
+		return nil, err
+	}
+	return backend.UpdateServiceTaskDefinition(ctx, cluster.Cluster, *service.ServiceArn, taskDefinitionArn)
+}
+
+// Stops the task with the reason, which is shown with the stopped task, returning the stopped task
+func StopTask(ctx context.Context, cluster *aws.EcsCluster, task *ecs.Task, reason string) (*ecs.Task, error) {
+	backend, err := actionBackendFor(cluster)
+	if err != nil {
+		return nil, err
+	}
+	return backend.StopTask(ctx, cluster.Cluster, *task.TaskArn, reason)
+}
+
+// Sets the status of the container instances to ACTIVE or DRAINING, returning the updated instances and the failures
+// for those that couldn't be updated. ECS replaces the service tasks on draining instances with tasks elsewhere.
+func SetContainerInstancesStatus(ctx context.Context, cluster *aws.EcsCluster, instances []*aws.EcsContainer, status string) ([]*ecs.ContainerInstance, []*ecs.Failure, error) {
+	backend, err := actionBackendFor(cluster)
+	if err != nil {
+		return nil, nil, err
+	}
+	arns := funk.Map(instances, func(instance *aws.EcsContainer) string { return *instance.ContainerInstanceArn }).([]string)
+	return backend.UpdateContainerInstancesState(ctx, cluster.Cluster, arns, status)
+}
+
+// Starts updating the ECS agent on the container instance to the latest version, returning the updated instance
+func UpdateContainerAgent(ctx context.Context, cluster *aws.EcsCluster, instance *aws.EcsContainer) (*ecs.ContainerInstance, error) {
+	backend, err := actionBackendFor(cluster)
+	if err != nil {
+		return nil, err
+	}
+	return backend.UpdateContainerAgent(ctx, cluster.Cluster, *instance.ContainerInstanceArn)
+}
+
+// Returns the arn of the task definition the service ran before its current one: the newest other task definition in
+// its deployments, which includes the one being replaced while a deployment rolls out, or else the revision before its
+// current one in the same family
+func GetPreviousTaskDefinition(ctx context.Context, cluster *aws.EcsCluster, service *ecs.Service) (string, error) {
+	deployments := append([]*ecs.Deployment{}, service.Deployments...)
+	sort.SliceStable(deployments, func(i, j int) bool {
+		return awssdk.TimeValue(deployments[i].CreatedAt).After(awssdk.TimeValue(deployments[j].CreatedAt))
+	})
+	for _, deployment := range deployments {
+		if deployment.TaskDefinition != nil && *deployment.TaskDefinition != *service.TaskDefinition {
+			return *deployment.TaskDefinition, nil
+		}
+	}
+
+	current := *service.TaskDefinition
+	revisions, err := GetTaskDefinitionRevisions(ctx, cluster, aws.TaskDefinitionFamily(current))
+	if err != nil {
+		return "", err
+	}
+	currentRevision := revisionNumber(current)
+	for _, arn := range revisions {
+		if revisionNumber(arn) < currentRevision {
+			return arn, nil
+		}
+	}
+	return "", fmt.Errorf("%s has no earlier active revision to roll back to", aws.ShortenTaskDefArn(&current))
+}
+
+// Returns the revision number at the end of a task definition arn, eg 42 for "…:task-definition/web:42"
+func revisionNumber(taskDefinitionArn string) int64 {
+	revision, _ := strconv.ParseInt(utils.RemoveAllRegex(`.*:`, taskDefinitionArn), 10, 64)
+	return revision
+}
+
+// Returns the Backend to act on the cluster with, or an error if it's no longer loaded
+func actionBackendFor(cluster *aws.EcsCluster) (aws.Backend, error) {
+	backend := backendFor(cluster)
+	if backend == nil {
+		return nil, fmt.Errorf("cluster %s is not in the selected regions", *cluster.ClusterName)
+	}
+	return backend, nil
+}
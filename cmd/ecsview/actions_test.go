@@ -0,0 +1,183 @@
+package ecsview
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	awssdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ecs"
+
+	"github.com/swartzrock/ecsview/cmd/aws"
+)
+
+const testTaskDefArn = "arn:aws:ecs:us-east-1:123456789012:task-definition/web:"
+
+func TestGetPreviousTaskDefinitionFromDeployments(t *testing.T) {
+	created := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	deployment := func(revision string, createdAt *time.Time) *ecs.Deployment {
+		return &ecs.Deployment{TaskDefinition: awssdk.String(testTaskDefArn + revision), CreatedAt: createdAt}
+	}
+	tests := []struct {
+		name        string
+		deployments []*ecs.Deployment
+		want        string
+	}{
+		{
+			name: "newest other deployment",
+			deployments: []*ecs.Deployment{
+				deployment("42", &created),
+				deployment("40", awssdk.Time(created.Add(-2*time.Hour))),
+				deployment("41", awssdk.Time(created.Add(-time.Hour))),
+			},
+			want: testTaskDefArn + "41",
+		},
+		{
+			name: "deployments without a creation time sort last",
+			deployments: []*ecs.Deployment{
+				deployment("40", nil),
+				deployment("42", &created),
+				deployment("41", awssdk.Time(created.Add(-time.Hour))),
+			},
+			want: testTaskDefArn + "41",
+		},
+		{
+			name: "deployments without a task definition are skipped",
+			deployments: []*ecs.Deployment{
+				deployment("42", &created),
+				{CreatedAt: awssdk.Time(created.Add(-time.Minute))},
+				deployment("41", awssdk.Time(created.Add(-time.Hour))),
+			},
+			want: testTaskDefArn + "41",
+		},
+		{
+			name:        "only deployments without a creation time",
+			deployments: []*ecs.Deployment{deployment("42", nil), deployment("41", nil)},
+			want:        testTaskDefArn + "41",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &ecs.Service{TaskDefinition: awssdk.String(testTaskDefArn + "42"), Deployments: tt.deployments}
+			cluster := &aws.EcsCluster{Cluster: &ecs.Cluster{ClusterName: awssdk.String("production")}}
+			got, err := GetPreviousTaskDefinition(context.Background(), cluster, service)
+			if err != nil || got != tt.want {
+				t.Errorf("GetPreviousTaskDefinition() = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetPreviousTaskDefinitionFromRevisions(t *testing.T) {
+	backend := newTestBackend()
+	for _, revision := range []int64{40, 41, 43, 42} {
+		backend.TaskDefinitions = append(backend.TaskDefinitions, &ecs.TaskDefinition{
+			TaskDefinitionArn: awssdk.String(fmt.Sprintf("%s%d", testTaskDefArn, revision)),
+			Revision:          awssdk.Int64(revision),
+		})
+	}
+	SetBackend(backend)
+	defer SetBackend(nil)
+	clusters, err := GetClusters(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		current string
+		want    string
+		wantErr bool
+	}{
+		{"the previous revision number, skipping newer ones", "42", testTaskDefArn + "41", false},
+		{"the newest revision", "43", testTaskDefArn + "42", false},
+		{"no earlier revision", "40", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The service's only deployment is of its current task definition, so the family's revisions are used
+			current := awssdk.String(testTaskDefArn + tt.current)
+			service := &ecs.Service{TaskDefinition: current, Deployments: []*ecs.Deployment{{TaskDefinition: current}}}
+			got, err := GetPreviousTaskDefinition(context.Background(), clusters[0], service)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("GetPreviousTaskDefinition() = %q, %v, want %q and error %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestStopTask(t *testing.T) {
+	taskArn := "arn:aws:ecs:us-east-1:123456789012:task/production/1"
+	backend := newTestBackend()
+	backend.Clusters[0].Tasks = []*ecs.Task{{
+		TaskArn:           awssdk.String(taskArn),
+		TaskDefinitionArn: awssdk.String(testTaskDefArn + "42"),
+		DesiredStatus:     awssdk.String(ecs.DesiredStatusRunning),
+	}}
+	SetBackend(backend)
+	defer SetBackend(nil)
+	clusters, err := GetClusters(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	task := backend.Clusters[0].Tasks[0]
+	stopped, err := StopTask(context.Background(), clusters[0], task, "testing")
+	if err != nil || *stopped.TaskArn != taskArn || *stopped.StoppedReason != "testing" {
+		t.Errorf("StopTask() = %v, %v, want the task stopped with the reason", stopped, err)
+	}
+
+	// A cluster that's no longer loaded can't be acted on
+	other := &aws.EcsCluster{Cluster: &ecs.Cluster{
+		ClusterArn:  awssdk.String("arn:aws:ecs:eu-west-1:123456789012:cluster/staging"),
+		ClusterName: awssdk.String("staging"),
+	}}
+	if _, err := StopTask(context.Background(), other, task, "testing"); err == nil {
+		t.Error("StopTask() in a cluster that isn't loaded succeeded")
+	}
+}
+
+func TestSetContainerInstancesStatus(t *testing.T) {
+	backend := newTestBackend()
+	backend.Clusters[0].ContainerInstances[0].Status = awssdk.String(ecs.ContainerInstanceStatusActive)
+	SetBackend(backend)
+	defer SetBackend(nil)
+	clusters, err := GetClusters(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	missing := &ecs.ContainerInstance{ContainerInstanceArn: awssdk.String("arn:aws:ecs:us-east-1:123456789012:container-instance/production/2")}
+	instances := aws.NewEcsContainers(append(backend.Clusters[0].ContainerInstances, missing))
+	updated, failures, err := SetContainerInstancesStatus(context.Background(), clusters[0], instances, ecs.ContainerInstanceStatusDraining)
+	if err != nil {
+		t.Fatalf("SetContainerInstancesStatus() error = %v", err)
+	}
+	if len(updated) != 1 || *updated[0].Status != ecs.ContainerInstanceStatusDraining {
+		t.Errorf("SetContainerInstancesStatus() updated %v, want the first instance draining", updated)
+	}
+	if len(failures) != 1 || *failures[0].Arn != *missing.ContainerInstanceArn {
+		t.Errorf("SetContainerInstancesStatus() failed for %v, want the unknown instance", failures)
+	}
+}
+
+func TestUpdateContainerAgent(t *testing.T) {
+	SetBackend(newTestBackend())
+	defer SetBackend(nil)
+	SetAgentVersionCheck(false)
+	defer SetAgentVersionCheck(true)
+	clusters, err := GetClusters(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := GetClusterData(context.Background(), clusters[0], nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	instance, err := UpdateContainerAgent(context.Background(), clusters[0], data.Containers[0])
+	if err != nil || *instance.AgentUpdateStatus != ecs.AgentUpdateStatusPending {
+		t.Errorf("UpdateContainerAgent() = %v, %v, want a pending update", instance, err)
+	}
+}
@@ -0,0 +1,73 @@
+package ecsview
+
+import (
+	"sync"
+	"time"
+)
+
+// How long loaded cluster data is used before it's reloaded, unless configured otherwise
+const DefaultCacheTTL = 5 * time.Minute
+
+// A thread-safe cache whose entries go stale a fixed time-to-live after they're stored.
+// Stale entries are kept so they can still be displayed while fresh ones load.
+type Cache struct {
+	mutex   sync.RWMutex
+	ttl     time.Duration
+	entries map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	value  interface{}
+	stored time.Time
+}
+
+// Returns an empty cache whose entries go stale after ttl, or never if ttl is zero
+func NewCache(ttl time.Duration) *Cache {
+	return &Cache{ttl: ttl, entries: make(map[string]cacheEntry)}
+}
+
+// Returns the fresh value stored under the key, or false if there is none or it's stale
+func (c *Cache) Get(key string) (interface{}, bool) {
+	value, fresh, found := c.Peek(key)
+	return value, found && fresh
+}
+
+// Returns the value stored under the key even if it's stale, whether it's fresh, and whether it was found at all
+func (c *Cache) Peek(key string) (value interface{}, fresh bool, found bool) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	entry, found := c.entries[key]
+	if !found {
+		return nil, false, false
+	}
+	return entry.value, c.ttl == 0 || time.Since(entry.stored) < c.ttl, true
+}
+
+// Stores the value under the key, replacing any previous value
+func (c *Cache) Put(key string, value interface{}) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.entries[key] = cacheEntry{value: value, stored: time.Now()}
+}
+
+// Removes the value stored under the key
+func (c *Cache) Delete(key string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	delete(c.entries, key)
+}
+
+// Removes every value
+func (c *Cache) Clear() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.entries = make(map[string]cacheEntry)
+}
+
+// Changes how long entries stay fresh, including entries already stored
+func (c *Cache) SetTTL(ttl time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.ttl = ttl
+}
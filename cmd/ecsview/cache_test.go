@@ -0,0 +1,120 @@
+package ecsview
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Stores the value as if it had been put age ago
+func putAged(c *Cache, key string, value interface{}, age time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.entries[key] = cacheEntry{value: value, stored: time.Now().Add(-age)}
+}
+
+func TestCacheStaleness(t *testing.T) {
+	tests := []struct {
+		name      string
+		ttl       time.Duration
+		age       time.Duration
+		wantFresh bool
+	}{
+		{"just stored", time.Minute, 0, true},
+		{"younger than the ttl", time.Minute, 30 * time.Second, true},
+		{"older than the ttl", time.Minute, 2 * time.Minute, false},
+		{"zero ttl never goes stale", 0, 24 * time.Hour, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewCache(tt.ttl)
+			putAged(c, "cluster", "data", tt.age)
+
+			value, fresh, found := c.Peek("cluster")
+			if !found || value != "data" || fresh != tt.wantFresh {
+				t.Errorf("Peek() = %v, %v, %v, want data, %v, true", value, fresh, found, tt.wantFresh)
+			}
+
+			value, found = c.Get("cluster")
+			if found != tt.wantFresh {
+				t.Errorf("Get() found = %v, want %v", found, tt.wantFresh)
+			}
+			if found && value != "data" {
+				t.Errorf("Get() = %v, want data", value)
+			}
+		})
+	}
+}
+
+func TestCacheMissing(t *testing.T) {
+	c := NewCache(time.Minute)
+	c.Put("deleted", 1)
+	c.Delete("deleted")
+	c.Put("cleared", 2)
+	c.Clear()
+
+	for _, key := range []string{"never stored", "deleted", "cleared"} {
+		if value, fresh, found := c.Peek(key); value != nil || fresh || found {
+			t.Errorf("Peek(%q) = %v, %v, %v, want nil, false, false", key, value, fresh, found)
+		}
+		if _, found := c.Get(key); found {
+			t.Errorf("Get(%q) found a value", key)
+		}
+	}
+}
+
+func TestCacheSetTTL(t *testing.T) {
+	tests := []struct {
+		name      string
+		ttl       time.Duration
+		wantFresh bool
+	}{
+		{"shorter ttl makes stored entries stale", time.Minute, false},
+		{"longer ttl makes stored entries fresh again", time.Hour, true},
+		{"zero ttl keeps stored entries forever", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewCache(DefaultCacheTTL)
+			putAged(c, "cluster", "data", 10*time.Minute)
+
+			c.SetTTL(tt.ttl)
+			if _, found := c.Get("cluster"); found != tt.wantFresh {
+				t.Errorf("Get() found = %v, want %v", found, tt.wantFresh)
+			}
+		})
+	}
+}
+
+func TestCacheConcurrentPutGet(t *testing.T) {
+	c := NewCache(time.Minute)
+
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		w := w
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				key := fmt.Sprintf("cluster-%d", i%10)
+				c.Put(key, w)
+				if value, found := c.Get(key); !found || value == nil {
+					t.Errorf("Get(%q) = %v, %v right after Put", key, value, found)
+				}
+				c.Peek(key)
+				if i%50 == 0 {
+					c.SetTTL(time.Minute)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	for i := 0; i < 10; i++ {
+		key := fmt.Sprintf("cluster-%d", i)
+		if _, found := c.Get(key); !found {
+			t.Errorf("Get(%q) found nothing after the writers finished", key)
+		}
+	}
+}
@@ -1,11 +1,16 @@
 package ecsview
 
 import (
-	"log"
+	"context"
+	"fmt"
 	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	awssdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/service/ecs"
 
 	"github.com/swartzrock/ecsview/cmd/aws"
@@ -17,116 +22,450 @@ type ClusterData struct {
 	Cluster          *aws.EcsCluster
 	Services         []*ecs.Service
 	Tasks            []*ecs.Task
+	StoppedTasks     []*ecs.Task
 	TaskDefArnLookup map[string]*ecs.TaskDefinition
+	TaskDefFamilies  []string
 	Containers       []*aws.EcsContainer
 	Refreshed        time.Time
+
+	// The latest released ECS Agent version, or nil if it couldn't be read from Github
+	LatestAgentVersion *string
+
+	// The error from loading this data, if some of it failed to load
+	Err error
+}
+
+// Returns the cluster's tasks that are placed on the container instance
+func (d *ClusterData) InstanceTasks(containerInstanceArn string) []*ecs.Task {
+	tasks := make([]*ecs.Task, 0)
+	for _, task := range d.Tasks {
+		if task.ContainerInstanceArn != nil && *task.ContainerInstanceArn == containerInstanceArn {
+			tasks = append(tasks, task)
+		}
+	}
+	return tasks
+}
+
+// Receives the name of each phase of a load as it begins, eg "services"
+type ProgressFunc func(phase string)
+
+func (p ProgressFunc) report(phase string) {
+	if p != nil {
+		p(phase)
+	}
 }
 
+// Guards the package state below, which is loaded in the background and read from the UI
+var mutex sync.Mutex
+
+var backends []aws.Backend
+var clusterArnToBackendMap = make(map[string]aws.Backend)
+var workPool = aws.NewWorkPool(aws.DefaultConcurrency)
 var clusters []*aws.EcsCluster
-var clusterArnToEcsContainersMap = make(map[string][]*aws.EcsContainer)
-var clusterArnToEcsDataMap = make(map[string]*ClusterData)
+var agentVersionCheck = true
+
+// Caches keyed by cluster arn, which are thread-safe on their own
+var clusterContainersCache = NewCache(DefaultCacheTTL)
+var clusterDataCache = NewCache(DefaultCacheTTL)
+var clusterErrorCache = NewCache(0)
+
+// Task definition families keyed by describeBackend, which are account-wide so every cluster in a region shares them
+var taskDefFamiliesCache = NewCache(DefaultCacheTTL)
 
-// Returns a slice of ECS Clusters. If this is the first time, the clusters and their instances are loaded and cached.
-func GetClusters() []*aws.EcsCluster {
-	if clusters == nil {
-		loadClustersAndContainers()
+// The latest released ECS Agent version, keyed by latestAgentVersionKey
+var agentVersionCache = NewCache(DefaultCacheTTL)
+
+const latestAgentVersionKey = "latest"
+
+// Sets the Backend used to load ECS data, dropping any data loaded from the previous Backends
+func SetBackend(b aws.Backend) {
+	SetBackends([]aws.Backend{b})
+}
+
+// Sets the Backends used to load ECS data, one per profile and region, dropping any data loaded from the previous Backends
+func SetBackends(b []aws.Backend) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	backends = b
+	clusters = nil
+	clusterArnToBackendMap = make(map[string]aws.Backend)
+	clusterContainersCache.Clear()
+	clusterDataCache.Clear()
+	clusterErrorCache.Clear()
+	taskDefinitionCache.Clear()
+	taskDefFamiliesCache.Clear()
+}
+
+// Sets how long loaded cluster data, instances, task definition families and the latest ECS Agent version are used
+// before they're reloaded, or forever if ttl is zero
+func SetCacheTTL(ttl time.Duration) {
+	clusterContainersCache.SetTTL(ttl)
+	clusterDataCache.SetTTL(ttl)
+	taskDefFamiliesCache.SetTTL(ttl)
+	agentVersionCache.SetTTL(ttl)
+}
+
+// Returns the regions of the current Backends
+func GetRegions() []string {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	regions := make([]string, 0, len(backends))
+	for _, b := range backends {
+		regions = append(regions, b.Region())
 	}
+	return regions
+}
+
+// Returns the profiles of the current Backends, with an empty string for the default credentials
+func GetProfiles() []string {
+	mutex.Lock()
+	defer mutex.Unlock()
 
-	return clusters
+	profiles := make([]string, 0, len(backends))
+	for _, b := range backends {
+		profiles = append(profiles, b.Profile())
+	}
+	return profiles
+}
+
+// Sets the pool used to load the clusters' container instances in parallel
+func SetWorkPool(pool *aws.WorkPool) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	workPool = pool
+}
+
+// Returns a slice of ECS Clusters from every profile and region. If this is the first time, the clusters and their instances are
+// loaded and cached. Clusters whose instances could not be loaded are still returned, with their error available from
+// GetClusterError. If some profiles or regions could not be loaded, the clusters from the others are returned with the error.
+func GetClusters(ctx context.Context, progress ProgressFunc) ([]*aws.EcsCluster, error) {
+	mutex.Lock()
+	loaded := clusters
+	mutex.Unlock()
+
+	if loaded != nil {
+		return loaded, nil
+	}
+	return loadClustersAndContainers(ctx, progress)
+}
+
+// Drops the cached clusters so that the next call to GetClusters reloads them
+func ClearClusters() {
+	mutex.Lock()
+	defer mutex.Unlock()
+	clusters = nil
+}
+
+// Returns data about the given cluster, along with the error from loading it if any part failed to load.
+// The data is loaded if it hasn't been yet or if the cached data is stale.
+func GetClusterData(ctx context.Context, cluster *aws.EcsCluster, progress ProgressFunc) (*ClusterData, error) {
+	if data, found := clusterDataCache.Get(*cluster.ClusterArn); found {
+		return data.(*ClusterData), data.(*ClusterData).Err
+	}
+	return loadAndSaveEcsData(ctx, cluster, progress)
 }
 
-// Returns data about the given cluster
-func GetClusterData(cluster *aws.EcsCluster) *ClusterData {
-	if data, found := clusterArnToEcsDataMap[*cluster.ClusterArn]; found {
-		return data
+// Returns the cached data about the given cluster even if it's stale, and whether it's fresh.
+// Returns nil if the cluster's data hasn't been loaded.
+func GetCachedClusterData(cluster *aws.EcsCluster) (*ClusterData, bool) {
+	data, fresh, found := clusterDataCache.Peek(*cluster.ClusterArn)
+	if !found {
+		return nil, false
 	}
-	return loadAndSaveEcsData(cluster)
+	return data.(*ClusterData), fresh
 }
 
-// Returns data about the cluster, freshly loaded from AWS
-func RefreshClusterData(cluster *aws.EcsCluster) *ClusterData {
-	clusterArnToEcsContainersMap[*cluster.ClusterArn] = nil
-	return loadAndSaveEcsData(cluster)
+// Returns data about the cluster, freshly loaded from AWS, along with the error from loading it if any part failed to load
+func RefreshClusterData(ctx context.Context, cluster *aws.EcsCluster, progress ProgressFunc) (*ClusterData, error) {
+	clusterContainersCache.Delete(*cluster.ClusterArn)
+	return loadAndSaveEcsData(ctx, cluster, progress)
 }
 
-// Returns the containers for a given cluster
+// Returns the containers for a given cluster, even if they're stale
 func GetClusterContainers(cluster *aws.EcsCluster) []*aws.EcsContainer {
-	return clusterArnToEcsContainersMap[*cluster.ClusterArn]
+	if containers, _, found := clusterContainersCache.Peek(*cluster.ClusterArn); found {
+		return containers.([]*aws.EcsContainer)
+	}
+	return nil
 }
 
-// Got an AWS error? Print an error message and suggest correctly configuring their AWS profile
-func fatalAwsError(err error) {
-	if err != nil {
-		log.Fatal("An issue occurred calling the AWS SDK. Please confirm you have the right AWS credentials in place. Error: ", err)
+// Returns the error from the most recent load of the given cluster's data, or nil if it loaded successfully
+func GetClusterError(cluster *aws.EcsCluster) error {
+	if err, found := clusterErrorCache.Get(*cluster.ClusterArn); found && err != nil {
+		return err.(error)
 	}
+	return nil
+}
+
+// Returns the Backend the cluster was loaded from
+func backendFor(cluster *aws.EcsCluster) aws.Backend {
+	mutex.Lock()
+	defer mutex.Unlock()
+	return clusterArnToBackendMap[*cluster.ClusterArn]
 }
 
-func loadClustersAndContainers() {
+func loadClustersAndContainers(ctx context.Context, progress ProgressFunc) ([]*aws.EcsCluster, error) {
+
+	mutex.Lock()
+	pool := workPool
+	regionBackends := backends
+	mutex.Unlock()
+
+	// Load each backend's clusters in parallel. A backend that fails records its error rather than stopping the rest,
+	// including one that AWS still throttles after the pool's retries.
+	progress.report("clusters")
+	backendClusters := make([][]*aws.EcsCluster, len(regionBackends))
+	backendErrs := pool.RunEach(ctx, len(regionBackends), func(ctx context.Context, i int) error {
+		var err error
+		backendClusters[i], err = loadBackendClusters(ctx, regionBackends[i])
+		return err
+	})
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	// Several profiles may read the same account, so skip clusters that were already loaded
+	loaded := make([]*aws.EcsCluster, 0)
+	loadedBackends := make(map[string]aws.Backend)
+	backendErrors := loadErrors{what: "clusters"}
+	for i, b := range regionBackends {
+		backendErrors.add(describeBackend(b), backendErrs[i])
+		for _, cluster := range backendClusters[i] {
+			if _, found := loadedBackends[*cluster.ClusterArn]; !found {
+				loadedBackends[*cluster.ClusterArn] = b
+				loaded = append(loaded, cluster)
+			}
+		}
+	}
+	if len(loaded) == 0 && backendErrors.err() != nil {
+		return nil, backendErrors.err()
+	}
 
-	clusterResults, err := aws.DescribeClusters()
-	fatalAwsError(err)
-	clusters = aws.NewEcsClusters(clusterResults)
+	sort.SliceStable(loaded, func(i, j int) bool {
+		if *loaded[i].ClusterName == *loaded[j].ClusterName {
+			return loaded[i].AccountId+loaded[i].Region < loaded[j].AccountId+loaded[j].Region
+		}
+		return 0 > strings.Compare(*loaded[i].ClusterName, *loaded[j].ClusterName)
+	})
 
-	sort.SliceStable(clusters, func(i, j int) bool {
-		return 0 > strings.Compare(*clusters[i].ClusterName, *clusters[j].ClusterName)
+	// Load each cluster's instances in parallel. A cluster that fails records its error rather than stopping the rest,
+	// including one that AWS still throttles after the pool's retries.
+	var loadedCount int32
+	progress.report(fmt.Sprintf("instances (0 of %d clusters)", len(loaded)))
+	clusterErrs := pool.RunEach(ctx, len(loaded), func(ctx context.Context, i int) error {
+		_, err := loadAndSaveClusterContainers(ctx, loadedBackends[*loaded[i].ClusterArn], loaded[i])
+		if !request.IsErrorThrottle(err) {
+			progress.report(fmt.Sprintf("instances (%d of %d clusters)", atomic.AddInt32(&loadedCount, 1), len(loaded)))
+		}
+		return err
 	})
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	for i, cluster := range loaded {
+		errs := loadErrors{what: "data for cluster " + *cluster.ClusterName}
+		errs.add("container instances", clusterErrs[i])
+		clusterErrorCache.Put(*cluster.ClusterArn, errs.err())
+	}
+
+	mutex.Lock()
+	clusters = loaded
+	for arn, b := range loadedBackends {
+		clusterArnToBackendMap[arn] = b
+	}
+	mutex.Unlock()
+
+	return loaded, backendErrors.err()
+}
+
+// Returns the clusters from the backend, labelled with the backend's profile and account
+func loadBackendClusters(ctx context.Context, b aws.Backend) ([]*aws.EcsCluster, error) {
+	clusterResults, err := b.DescribeClusters(ctx)
+	if err != nil {
+		return nil, err
+	}
+	loaded := aws.NewEcsClusters(clusterResults, b.Region())
+
+	// Fall back to the account ids in the cluster arns if the caller identity isn't available
+	accountId, err := b.GetAccountId(ctx)
+	for _, cluster := range loaded {
+		cluster.Profile = b.Profile()
+		if err == nil && accountId != "" {
+			cluster.AccountId = accountId
+		}
+	}
+	return loaded, nil
+}
 
-	totalInstances := 0
-	for _, cluster := range clusters {
-		totalInstances += len(loadAndSaveClusterContainers(cluster))
+// Returns the backend's profile and region for error messages, eg "prod/us-east-1"
+func describeBackend(b aws.Backend) string {
+	if b.Profile() == "" {
+		return b.Region()
 	}
+	return b.Profile() + "/" + b.Region()
 }
 
-func loadAndSaveEcsData(cluster *aws.EcsCluster) *ClusterData {
+func loadAndSaveEcsData(ctx context.Context, cluster *aws.EcsCluster, progress ProgressFunc) (*ClusterData, error) {
+
+	errs := loadErrors{what: "data for cluster " + *cluster.ClusterName}
+	backend := backendFor(cluster)
+	if backend == nil {
+		return nil, fmt.Errorf("cluster %s is not in the selected regions", *cluster.ClusterName)
+	}
 
-	services, err := aws.DescribeClusterServices(cluster.Cluster)
-	fatalAwsError(err)
+	progress.report("services")
+	services, err := backend.DescribeClusterServices(ctx, cluster.Cluster)
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	errs.add("services", err)
 	sort.SliceStable(services, func(i, j int) bool {
 		return 0 > strings.Compare(*services[i].ServiceName, *services[j].ServiceName)
 	})
 
-	tasks, err := aws.DescribeClusterTasks(cluster.Cluster)
-	fatalAwsError(err)
+	progress.report("tasks")
+	tasks, err := backend.DescribeClusterTasks(ctx, cluster.Cluster)
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	errs.add("tasks", err)
 	sort.SliceStable(tasks, func(i, j int) bool {
 		return 0 > strings.Compare(utils.RemoveAllRegex(`.*/`, *tasks[i].TaskDefinitionArn), utils.RemoveAllRegex(`.*/`, *tasks[j].TaskDefinitionArn))
 	})
 
-	taskDefinitions, err := aws.GetTaskDefinitions(tasks)
-	fatalAwsError(err)
+	progress.report("stopped tasks")
+	stoppedTasks, err := backend.DescribeClusterStoppedTasks(ctx, cluster.Cluster)
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	errs.add("stopped tasks", err)
+	sort.SliceStable(stoppedTasks, func(i, j int) bool {
+		return stoppedAt(stoppedTasks[i]).After(stoppedAt(stoppedTasks[j]))
+	})
+
+	progress.report("task definitions")
+	taskDefinitions, err := backend.GetTaskDefinitions(ctx, tasks)
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	errs.add("task definitions", err)
 	taskDefinitionArnLookup := make(map[string]*ecs.TaskDefinition)
 	for _, taskDef := range taskDefinitions {
 		taskDefinitionArnLookup[*taskDef.TaskDefinitionArn] = taskDef
+		taskDefinitionCache.Put(*taskDef.TaskDefinitionArn, taskDef)
+	}
+
+	taskDefFamilies, err := getTaskDefinitionFamilies(ctx, backend, progress)
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
 	}
+	errs.add("task definition families", err)
 
-	containerPluses := clusterArnToEcsContainersMap[*cluster.ClusterArn]
-	// Check if the user refreshed, clearing our cache of instances
-	if containerPluses == nil {
-		containerPluses = loadAndSaveClusterContainers(cluster)
+	// Reload the instances if the user refreshed, clearing our cache of instances, or if they're stale
+	var containerPluses []*aws.EcsContainer
+	if containers, found := clusterContainersCache.Get(*cluster.ClusterArn); found {
+		containerPluses = containers.([]*aws.EcsContainer)
+	} else {
+		progress.report("instances")
+		containerPluses, err = loadAndSaveClusterContainers(ctx, backend, cluster)
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		errs.add("container instances", err)
 	}
 
 	data := &ClusterData{
-		Cluster:          cluster,
-		Services:         services,
-		Tasks:            tasks,
-		TaskDefArnLookup: taskDefinitionArnLookup,
-		Containers:       containerPluses,
-		Refreshed:        time.Now(),
+		Cluster:            cluster,
+		Services:           services,
+		Tasks:              tasks,
+		StoppedTasks:       stoppedTasks,
+		TaskDefArnLookup:   taskDefinitionArnLookup,
+		TaskDefFamilies:    taskDefFamilies,
+		Containers:         containerPluses,
+		Refreshed:          time.Now(),
+		LatestAgentVersion: getLatestAgentVersion(ctx, progress),
+		Err:                errs.err(),
 	}
 
-	clusterArnToEcsDataMap[*cluster.ClusterArn] = data
+	clusterDataCache.Put(*cluster.ClusterArn, data)
+	clusterErrorCache.Put(*cluster.ClusterArn, data.Err)
 
-	return data
+	return data, data.Err
 }
 
-func loadAndSaveClusterContainers(cluster *aws.EcsCluster) []*aws.EcsContainer {
+func loadAndSaveClusterContainers(ctx context.Context, backend aws.Backend, cluster *aws.EcsCluster) ([]*aws.EcsContainer, error) {
 
-	containers, err := aws.DescribeContainerInstances(cluster.Cluster)
-	fatalAwsError(err)
+	containers, err := backend.DescribeContainerInstances(ctx, cluster.Cluster)
+	if err != nil {
+		return nil, err
+	}
 	sort.SliceStable(containers, func(i, j int) bool {
-		return 0 > strings.Compare(*containers[i].Ec2InstanceId, *containers[j].Ec2InstanceId)
+		return 0 > strings.Compare(awssdk.StringValue(containers[i].Ec2InstanceId), awssdk.StringValue(containers[j].Ec2InstanceId))
 	})
 	containerPluses := aws.NewEcsContainers(containers)
 
-	clusterArnToEcsContainersMap[*cluster.ClusterArn] = containerPluses
-	return containerPluses
+	clusterContainersCache.Put(*cluster.ClusterArn, containerPluses)
+
+	return containerPluses, nil
+}
+
+// Returns the backend's active task definition families, sorted, listing them only when they're stale
+func getTaskDefinitionFamilies(ctx context.Context, backend aws.Backend, progress ProgressFunc) ([]string, error) {
+	key := describeBackend(backend)
+	if families, found := taskDefFamiliesCache.Get(key); found {
+		return families.([]string), nil
+	}
+
+	progress.report("task definition families")
+	families, err := backend.ListTaskDefinitionFamilies(ctx)
+	if err != nil {
+		return families, err
+	}
+	sort.Strings(families)
+	taskDefFamiliesCache.Put(key, families)
+	return families, nil
+}
+
+// Sets whether loads read the latest released ECS Agent version from Github
+func SetAgentVersionCheck(enabled bool) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	agentVersionCheck = enabled
+}
+
+// Returns the latest released ECS Agent version, reading it from Github again once the cached version is stale
+func getLatestAgentVersion(ctx context.Context, progress ProgressFunc) *string {
+	mutex.Lock()
+	enabled := agentVersionCheck
+	mutex.Unlock()
+
+	if !enabled {
+		return nil
+	}
+	if version, found := agentVersionCache.Get(latestAgentVersionKey); found {
+		return version.(*string)
+	}
+
+	progress.report("latest ECS agent version")
+	version, _ := aws.GetLatestECSAgentVersion(ctx)
+
+	// A cancelled load hasn't really checked, so leave it to the next load
+	if ctx.Err() == nil {
+		agentVersionCache.Put(latestAgentVersionKey, version)
+	}
+	return version
+}
+
+// Returns when the task stopped, falling back to when it was told to stop
+func stoppedAt(task *ecs.Task) time.Time {
+	if task.StoppedAt != nil {
+		return *task.StoppedAt
+	}
+	if task.StoppingAt != nil {
+		return *task.StoppingAt
+	}
+	return time.Time{}
 }
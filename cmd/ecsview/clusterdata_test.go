@@ -0,0 +1,113 @@
+package ecsview
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	awssdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ecs"
+
+	"github.com/swartzrock/ecsview/cmd/aws"
+)
+
+// A fixture backend whose tasks fail to load
+type failingTasksBackend struct {
+	*aws.FixtureBackend
+	err error
+}
+
+func (b *failingTasksBackend) DescribeClusterTasks(ctx context.Context, c *ecs.Cluster) ([]*ecs.Task, error) {
+	return nil, b.err
+}
+
+// A fixture backend whose container instances fail to load for one cluster
+type failingInstancesBackend struct {
+	*aws.FixtureBackend
+	clusterName string
+	err         error
+}
+
+func (b *failingInstancesBackend) DescribeContainerInstances(ctx context.Context, c *ecs.Cluster) ([]*ecs.ContainerInstance, error) {
+	if *c.ClusterName == b.clusterName {
+		return nil, b.err
+	}
+	return b.FixtureBackend.DescribeContainerInstances(ctx, c)
+}
+
+// Returns a fixture backend with a cluster holding a service and an instance
+func newTestBackend() *aws.FixtureBackend {
+	return &aws.FixtureBackend{Clusters: []*aws.FixtureCluster{{
+		Cluster: &ecs.Cluster{
+			ClusterArn:  awssdk.String("arn:aws:ecs:us-east-1:123456789012:cluster/production"),
+			ClusterName: awssdk.String("production"),
+		},
+		Services: []*ecs.Service{{ServiceName: awssdk.String("web")}},
+		ContainerInstances: []*ecs.ContainerInstance{{
+			ContainerInstanceArn: awssdk.String("arn:aws:ecs:us-east-1:123456789012:container-instance/production/1"),
+			Ec2InstanceId:        awssdk.String("i-0a1b2c3d4e5f60718"),
+		}},
+	}}}
+}
+
+func TestGetClusterDataPartialLoad(t *testing.T) {
+	errDenied := errors.New("AccessDeniedException: ecs:ListTasks")
+	SetBackend(&failingTasksBackend{newTestBackend(), errDenied})
+	defer SetBackend(nil)
+	SetAgentVersionCheck(false)
+	defer SetAgentVersionCheck(true)
+
+	clusters, err := GetClusters(context.Background(), nil)
+	if err != nil || len(clusters) != 1 {
+		t.Fatalf("GetClusters() = %v, %v, want the cluster", clusters, err)
+	}
+
+	// What loaded is returned with the error, which is kept for the cluster
+	data, err := GetClusterData(context.Background(), clusters[0], nil)
+	if !errorsContain(err, errDenied) {
+		t.Errorf("GetClusterData() error = %v, want one with %v", err, errDenied)
+	}
+	if data == nil || len(data.Services) != 1 || len(data.Containers) != 1 || len(data.Tasks) != 0 {
+		t.Fatalf("GetClusterData() = %+v, want the service and instance without tasks", data)
+	}
+	if GetClusterError(clusters[0]) != err {
+		t.Errorf("GetClusterError() = %v, want %v", GetClusterError(clusters[0]), err)
+	}
+}
+
+func TestGetClustersKeepsLoadedClusters(t *testing.T) {
+	errDenied := errors.New("AccessDeniedException: ecs:ListContainerInstances")
+	b := newTestBackend()
+	b.Clusters = append(b.Clusters, &aws.FixtureCluster{Cluster: &ecs.Cluster{
+		ClusterArn:  awssdk.String("arn:aws:ecs:us-east-1:123456789012:cluster/staging"),
+		ClusterName: awssdk.String("staging"),
+	}})
+	SetBackend(&failingInstancesBackend{b, "staging", errDenied})
+	defer SetBackend(nil)
+
+	// The cluster whose instances failed is kept with its error, alongside the cluster that loaded
+	clusters, err := GetClusters(context.Background(), nil)
+	if err != nil || len(clusters) != 2 {
+		t.Fatalf("GetClusters() = %v, %v, want both clusters", clusters, err)
+	}
+	if *clusters[0].ClusterName != "production" || GetClusterError(clusters[0]) != nil || len(GetClusterContainers(clusters[0])) != 1 {
+		t.Errorf("production loaded with error %v and %d instances, want its instance", GetClusterError(clusters[0]), len(GetClusterContainers(clusters[0])))
+	}
+	if !errorsContain(GetClusterError(clusters[1]), errDenied) {
+		t.Errorf("GetClusterError(staging) = %v, want one with %v", GetClusterError(clusters[1]), errDenied)
+	}
+}
+
+// Returns true if err is a LoadError with an error wrapping target
+func errorsContain(err error, target error) bool {
+	var loadErr *LoadError
+	if !errors.As(err, &loadErr) {
+		return false
+	}
+	for _, e := range loadErr.Errors {
+		if errors.Is(e, target) {
+			return true
+		}
+	}
+	return false
+}
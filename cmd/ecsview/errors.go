@@ -0,0 +1,41 @@
+package ecsview
+
+import (
+	"fmt"
+	"strings"
+)
+
+// The AWS errors that occurred while loading data, eg about a cluster. Whatever did load is still returned alongside it.
+type LoadError struct {
+	What   string
+	Errors []error
+}
+
+func (e *LoadError) Error() string {
+	messages := make([]string, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		messages = append(messages, err.Error())
+	}
+	return fmt.Sprintf("unable to load all %s: %s", e.What, strings.Join(messages, "; "))
+}
+
+// Collects the errors from each phase of a load, eg loading a cluster's data
+type loadErrors struct {
+	what   string
+	errors []error
+}
+
+// Records the error, if any, from loading one kind of data
+func (l *loadErrors) add(what string, err error) {
+	if err != nil {
+		l.errors = append(l.errors, fmt.Errorf("%s: %w", what, err))
+	}
+}
+
+// Returns a LoadError with the recorded errors, or nil if there were none
+func (l *loadErrors) err() error {
+	if len(l.errors) == 0 {
+		return nil
+	}
+	return &LoadError{What: l.what, Errors: l.errors}
+}
@@ -0,0 +1,57 @@
+package ecsview
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestLoadErrors(t *testing.T) {
+	errDenied := errors.New("AccessDeniedException: denied")
+	errThrottled := errors.New("ThrottlingException: rate exceeded")
+	tests := []struct {
+		name string
+		errs map[string]error
+		want string
+	}{
+		{"no errors", map[string]error{"services": nil, "tasks": nil}, ""},
+		{"one error", map[string]error{"services": nil, "tasks": errDenied},
+			"unable to load all data for cluster production: tasks: AccessDeniedException: denied"},
+		{"errors in the order they were added", map[string]error{"services": errDenied, "tasks": errThrottled},
+			"unable to load all data for cluster production: services: AccessDeniedException: denied; " +
+				"tasks: ThrottlingException: rate exceeded"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := loadErrors{what: "data for cluster production"}
+			added := make([]error, 0)
+			for _, what := range []string{"services", "tasks"} {
+				errs.add(what, tt.errs[what])
+				if tt.errs[what] != nil {
+					added = append(added, tt.errs[what])
+				}
+			}
+
+			err := errs.err()
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("err() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.want {
+				t.Fatalf("err() = %v, want %q", err, tt.want)
+			}
+
+			// The recorded errors wrap the originals, so callers can still tell what went wrong
+			var loadErr *LoadError
+			if !errors.As(err, &loadErr) || loadErr.What != "data for cluster production" || len(loadErr.Errors) != len(added) {
+				t.Fatalf("err() = %#v, want a *LoadError for production with %d errors", err, len(added))
+			}
+			for i, original := range added {
+				if !errors.Is(loadErr.Errors[i], original) {
+					t.Errorf("recorded error %v doesn't wrap %v", loadErr.Errors[i], original)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package ecsview
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/service/ecs"
+
+	"github.com/swartzrock/ecsview/cmd/aws"
+)
+
+// Task definitions keyed by arn. A revision never changes once it's registered, so they're kept until the Backends change.
+var taskDefinitionCache = NewCache(0)
+
+// Returns the arns of the active revisions of a task definition family in the cluster's account and region, newest first
+func GetTaskDefinitionRevisions(ctx context.Context, cluster *aws.EcsCluster, family string) ([]string, error) {
+	backend := backendFor(cluster)
+	if backend == nil {
+		return nil, fmt.Errorf("cluster %s is not in the selected regions", *cluster.ClusterName)
+	}
+	return backend.ListTaskDefinitionRevisions(ctx, family)
+}
+
+// Returns the task definition with the given arn from the cluster's account and region, loading it only once
+func GetTaskDefinition(ctx context.Context, cluster *aws.EcsCluster, taskDefinitionArn string) (*ecs.TaskDefinition, error) {
+	if taskDef, found := taskDefinitionCache.Get(taskDefinitionArn); found {
+		return taskDef.(*ecs.TaskDefinition), nil
+	}
+
+	backend := backendFor(cluster)
+	if backend == nil {
+		return nil, fmt.Errorf("cluster %s is not in the selected regions", *cluster.ClusterName)
+	}
+	taskDef, err := backend.DescribeTaskDefinition(ctx, taskDefinitionArn)
+	if err != nil {
+		return nil, err
+	}
+	taskDefinitionCache.Put(taskDefinitionArn, taskDef)
+	return taskDef, nil
+}
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+
+	"github.com/swartzrock/ecsview/cmd/ui"
+)
+
+const filterInputName = "filter"
+
+// Show an input line over the footer that filters the focused table as the user types. Enter keeps the filter and
+// Escape clears it.
+func showFilterInput() {
+	table, rows := getFocusedTableRows()
+	if rows == nil {
+		return
+	}
+
+	input := tview.NewInputField().
+		SetLabel("/").
+		SetLabelColor(tcell.ColorYellow).
+		SetFieldBackgroundColor(tcell.ColorDefault).
+		SetText(rows.Filter())
+	input.SetChangedFunc(func(text string) {
+		filterTable(table, rows, text)
+	})
+	input.SetDoneFunc(func(key tcell.Key) {
+		if key == tcell.KeyEscape {
+			filterTable(table, rows, "")
+		}
+		rootPages.RemovePage(filterInputName)
+		tviewApp.SetFocus(table)
+	})
+
+	// Leave everything but the bottom line empty so the main view shows through
+	layout := tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(nil, 0, 1, false).
+		AddItem(input, 1, 0, true)
+	rootPages.AddPage(filterInputName, layout, true, true)
+}
+
+// Returns the table with focus and its rows, or the front cluster details page if neither table has focus
+func getFocusedTableRows() (*tview.Table, *ui.TableRows) {
+	if clusterTable.HasFocus() {
+		return clusterTable, clusterTableRows
+	}
+	if page := getFrontClusterDetailsPage(); page != nil {
+		return page.GetTable(), page.Rows
+	}
+	return nil, nil
+}
+
+func filterTable(table *tview.Table, rows *ui.TableRows, filter string) {
+	rows.SetFilter(filter)
+
+	// A filtered cluster table may have a different cluster selected
+	if table == clusterTable && rows.Shown() > 0 {
+		selectedRow, _ := table.GetSelection()
+		if selectedRow < 1 {
+			selectedRow = 1
+		}
+		table.Select(selectedRow, 0)
+	}
+}
@@ -0,0 +1,309 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/ecs"
+
+	"github.com/swartzrock/ecsview/cmd/aws"
+	"github.com/swartzrock/ecsview/cmd/ecsview"
+	"github.com/swartzrock/ecsview/cmd/pages"
+	"github.com/swartzrock/ecsview/cmd/utils"
+)
+
+// How often the instances page reloads the cluster while tasks drain off its instances or their agents update
+const instanceRefreshInterval = 5 * time.Second
+
+// Returns the container instances in the page's marked rows, or in its selected row if none are marked
+func getMarkedInstances(page *pages.ClusterDetailsPage, ecsData *ecsview.ClusterData) []*aws.EcsContainer {
+	instances := make([]*aws.EcsContainer, 0)
+	for _, arn := range getMarkedRowIds(page) {
+		for _, instance := range ecsData.Containers {
+			if *instance.ContainerInstanceArn == arn {
+				instances = append(instances, instance)
+			}
+		}
+	}
+	return instances
+}
+
+// Ask the user to confirm draining the marked or selected instances, showing how many of their tasks ECS will move,
+// then drain them and reload the cluster until their tasks are gone
+func drainMarkedInstances(page *pages.ClusterDetailsPage, cluster *aws.EcsCluster, ecsData *ecsview.ClusterData) {
+	instances := getMarkedInstances(page, ecsData)
+	if len(instances) == 0 {
+		return
+	}
+
+	// ECS replaces the tasks services started on draining instances, while other tasks keep running until they stop
+	lines := make([]string, 0, len(instances))
+	rescheduled, remaining := 0, 0
+	for _, instance := range instances {
+		serviceTasks, otherTasks := 0, 0
+		for _, task := range ecsData.InstanceTasks(*instance.ContainerInstanceArn) {
+			if task.Group != nil && strings.HasPrefix(*task.Group, "service:") {
+				serviceTasks++
+			} else {
+				otherTasks++
+			}
+		}
+		rescheduled += serviceTasks
+		remaining += otherTasks
+		lines = append(lines, fmt.Sprintf("%s (%s, %s)", describeInstance(instance), utils.LowerTitle(*instance.Status),
+			describeTaskCount(serviceTasks+otherTasks)))
+	}
+
+	text := fmt.Sprintf("Drain %s in %s?\n\n%s\n\nECS will reschedule %s started by services onto other instances.",
+		describeInstanceCount(len(instances)), *cluster.ClusterName, strings.Join(lines, "\n"), describeTaskCount(rescheduled))
+	if remaining > 0 {
+		text = fmt.Sprintf("%s The other %s keep running until they stop.", text, describeTaskCount(remaining))
+	}
+	showConfirmModal(text, "Drain", func() {
+		setInstancesStatus(page, cluster, instances, ecs.ContainerInstanceStatusDraining)
+	})
+}
+
+// Ask the user to confirm returning the marked or selected instances to service, then reactivate them
+func activateMarkedInstances(page *pages.ClusterDetailsPage, cluster *aws.EcsCluster, ecsData *ecsview.ClusterData) {
+	instances := getMarkedInstances(page, ecsData)
+	if len(instances) == 0 {
+		return
+	}
+
+	lines := make([]string, 0, len(instances))
+	for _, instance := range instances {
+		lines = append(lines, fmt.Sprintf("%s (%s)", describeInstance(instance), utils.LowerTitle(*instance.Status)))
+	}
+	them := "them"
+	if len(instances) == 1 {
+		them = "it"
+	}
+	text := fmt.Sprintf("Reactivate %s in %s?\n\n%s\n\nECS can place new tasks on %s again.",
+		describeInstanceCount(len(instances)), *cluster.ClusterName, strings.Join(lines, "\n"), them)
+	showConfirmModal(text, "Reactivate", func() {
+		setInstancesStatus(page, cluster, instances, ecs.ContainerInstanceStatusActive)
+	})
+}
+
+// Set the instances' status, then show which were updated and which failed. Draining instances are followed until
+// their tasks have moved off them.
+func setInstancesStatus(page *pages.ClusterDetailsPage, cluster *aws.EcsCluster, instances []*aws.EcsContainer, status string) {
+	var failures []*ecs.Failure
+	what := fmt.Sprintf("Setting %s to %s", describeInstanceCount(len(instances)), utils.LowerTitle(status))
+	runAction(what, func(ctx context.Context) error {
+		var err error
+		if _, failures, err = ecsview.SetContainerInstancesStatus(ctx, cluster, instances, status); err != nil {
+			return err
+		}
+		_, _ = ecsview.RefreshClusterData(ctx, cluster, nil)
+		return nil
+	}, func() {
+		page.Rows.ClearMarks()
+		renderActionResult(cluster)
+		showResultModal(describeUpdatedInstances(instances, failures, utils.LowerTitle(status)))
+		if status == ecs.ContainerInstanceStatusDraining {
+			followInstances(cluster, instances, draining)
+		}
+	})
+}
+
+// Describes which of the instances were updated and why the others weren't, eg "✔ i-0a1b2c3d4e5f60718 Draining"
+func describeUpdatedInstances(instances []*aws.EcsContainer, failures []*ecs.Failure, status string) string {
+	failed := make(map[string]*ecs.Failure)
+	for _, failure := range failures {
+		if failure.Arn != nil {
+			failed[*failure.Arn] = failure
+		}
+	}
+
+	updated := 0
+	lines := make([]string, 0, len(instances))
+	for _, instance := range instances {
+		failure, found := failed[*instance.ContainerInstanceArn]
+		if !found {
+			updated++
+			lines = append(lines, fmt.Sprintf("✔ %s %s", describeInstance(instance), status))
+			continue
+		}
+		reason := valueOrUnknown(failure.Reason)
+		if failure.Detail != nil {
+			reason = fmt.Sprintf("%s (%s)", reason, *failure.Detail)
+		}
+		lines = append(lines, fmt.Sprintf("✘ %s: %s", describeInstance(instance), reason))
+	}
+	return fmt.Sprintf("Set %d of %s to %s:\n\n%s", updated, describeInstanceCount(len(instances)), status,
+		strings.Join(lines, "\n"))
+}
+
+// Reload the cluster every few seconds while it's selected and any of the instances is still in progress, eg draining,
+// so their rows show the progress
+func followInstances(cluster *aws.EcsCluster, instances []*aws.EcsContainer, inProgress func(ecsData *ecsview.ClusterData, instance *aws.EcsContainer) bool) {
+	anyInProgress := func(ecsData *ecsview.ClusterData) bool {
+		for _, instance := range instances {
+			for _, container := range ecsData.Containers {
+				if *container.ContainerInstanceArn == *instance.ContainerInstanceArn && inProgress(ecsData, container) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	finished := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(instanceRefreshInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-finished:
+				return
+			case <-ticker.C:
+			}
+
+			tviewApp.QueueUpdateDraw(func() {
+				select {
+				case <-finished:
+					return
+				default:
+				}
+				ecsData, _ := ecsview.GetCachedClusterData(cluster)
+				if getCurrentlySelectedCluster() != cluster || ecsData == nil || !anyInProgress(ecsData) {
+					close(finished)
+					return
+				}
+				if currentLoad == nil && !isModalShowing() {
+					autoRefreshCluster(cluster)
+				}
+			})
+		}
+	}()
+}
+
+// Returns true if the instance is still draining and has tasks on it
+func draining(ecsData *ecsview.ClusterData, instance *aws.EcsContainer) bool {
+	return *instance.Status == ecs.ContainerInstanceStatusDraining && len(ecsData.InstanceTasks(*instance.ContainerInstanceArn)) > 0
+}
+
+// Returns true if the instance's agent is still being updated
+func updatingAgent(ecsData *ecsview.ClusterData, instance *aws.EcsContainer) bool {
+	return aws.AgentUpdateInProgress(instance.AgentUpdateStatus)
+}
+
+// Ask the user to confirm updating the agents of the marked or selected instances, then update them
+func updateMarkedInstanceAgents(page *pages.ClusterDetailsPage, cluster *aws.EcsCluster, ecsData *ecsview.ClusterData) {
+	confirmUpdateAgents(page, cluster, ecsData, getMarkedInstances(page, ecsData))
+}
+
+// Ask the user to confirm updating the agents of every instance with an outdated agent, then update them
+func updateOutdatedInstanceAgents(page *pages.ClusterDetailsPage, cluster *aws.EcsCluster, ecsData *ecsview.ClusterData) {
+	if ecsData.LatestAgentVersion == nil {
+		showResultModal("The latest ECS agent version couldn't be read from GitHub, which ecsview skips with fixtures " +
+			"or a custom endpoint, so it can't tell which agents are outdated. Mark the instances to update with " +
+			"Space and press u instead.")
+		return
+	}
+	outdated := make([]*aws.EcsContainer, 0)
+	for _, instance := range ecsData.Containers {
+		if instance.AgentOutdated(ecsData.LatestAgentVersion) && !aws.AgentUpdateInProgress(instance.AgentUpdateStatus) {
+			outdated = append(outdated, instance)
+		}
+	}
+	if len(outdated) == 0 {
+		showResultModal(fmt.Sprintf("Every instance in %s is running ECS agent %s or is already updating.",
+			*cluster.ClusterName, *ecsData.LatestAgentVersion))
+		return
+	}
+	confirmUpdateAgents(page, cluster, ecsData, outdated)
+}
+
+// Ask the user to confirm updating the instances' agents, then start the updates, show which started and which
+// failed to, and reload the cluster until they finish
+func confirmUpdateAgents(page *pages.ClusterDetailsPage, cluster *aws.EcsCluster, ecsData *ecsview.ClusterData, instances []*aws.EcsContainer) {
+	if len(instances) == 0 {
+		return
+	}
+
+	latest := "the latest version"
+	if ecsData.LatestAgentVersion != nil {
+		latest = *ecsData.LatestAgentVersion
+	}
+	lines := make([]string, 0, len(instances))
+	for _, instance := range instances {
+		var agentVersion *string
+		if instance.VersionInfo != nil {
+			agentVersion = instance.VersionInfo.AgentVersion
+		}
+		lines = append(lines, fmt.Sprintf("%s (%s)", describeInstance(instance), valueOrUnknown(agentVersion)))
+	}
+	their := "Their"
+	if len(instances) == 1 {
+		their = "Its"
+	}
+	text := fmt.Sprintf("Update the ECS agent on %s in %s to %s?\n\n%s\n\n%s tasks keep running while the agent restarts.",
+		describeInstanceCount(len(instances)), *cluster.ClusterName, latest, strings.Join(lines, "\n"), their)
+
+	showConfirmModal(text, "Update", func() {
+		var errs []error
+		runAction(fmt.Sprintf("Updating the ECS agent on %s", describeInstanceCount(len(instances))), func(ctx context.Context) error {
+			// Start every update even if some fail, so the user sees what happened to each of them
+			errs = workPool.RunEach(ctx, len(instances), func(ctx context.Context, i int) error {
+				_, err := ecsview.UpdateContainerAgent(ctx, cluster, instances[i])
+				return err
+			})
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			_, _ = ecsview.RefreshClusterData(ctx, cluster, nil)
+			return nil
+		}, func() {
+			page.Rows.ClearMarks()
+			renderActionResult(cluster)
+			showResultModal(describeAgentUpdates(instances, errs))
+			followInstances(cluster, instances, updatingAgent)
+		})
+	})
+}
+
+// Describes which of the instances' agent updates started and why the others didn't
+func describeAgentUpdates(instances []*aws.EcsContainer, errs []error) string {
+	started := 0
+	lines := make([]string, 0, len(instances))
+	for i, instance := range instances {
+		if errs[i] != nil {
+			lines = append(lines, fmt.Sprintf("✘ %s: %v", describeInstance(instance), errs[i]))
+		} else {
+			started++
+			lines = append(lines, fmt.Sprintf("✔ %s", describeInstance(instance)))
+		}
+	}
+	return fmt.Sprintf("Started updating the ECS agent on %d of %s:\n\n%s\n\nThe ECS Agent column shows each update's "+
+		"progress.", started, describeInstanceCount(len(instances)), strings.Join(lines, "\n"))
+}
+
+// Describes an instance by its EC2 instance id or, for an external instance which has none, by the container instance
+// id that ends its arn
+func describeInstance(instance *aws.EcsContainer) string {
+	if instance.Ec2InstanceId != nil {
+		return *instance.Ec2InstanceId
+	}
+	return utils.RemoveAllRegex(`.*/`, *instance.ContainerInstanceArn)
+}
+
+// Returns eg "1 instance" or "3 instances"
+func describeInstanceCount(count int) string {
+	if count == 1 {
+		return "1 instance"
+	}
+	return fmt.Sprintf("%d instances", count)
+}
+
+// Returns the string's value, or "unknown" if it's nil
+func valueOrUnknown(s *string) string {
+	if s == nil {
+		return "unknown"
+	}
+	return *s
+}
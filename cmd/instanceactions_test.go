@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"errors"
+	"testing"
+
+	awssdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ecs"
+
+	"github.com/swartzrock/ecsview/cmd/aws"
+)
+
+func TestDescribeUpdatedInstances(t *testing.T) {
+	arn := func(id string) string {
+		return "arn:aws:ecs:us-east-1:123456789012:container-instance/production/" + id
+	}
+	instances := aws.NewEcsContainers([]*ecs.ContainerInstance{
+		{ContainerInstanceArn: awssdk.String(arn("1")), Ec2InstanceId: awssdk.String("i-0a1b2c3d4e5f60718")},
+		// External instances have no EC2 instance id
+		{ContainerInstanceArn: awssdk.String(arn("2"))},
+		{ContainerInstanceArn: awssdk.String(arn("3")), Ec2InstanceId: awssdk.String("i-0f1e2d3c4b5a69788")},
+	})
+
+	tests := []struct {
+		name     string
+		failures []*ecs.Failure
+		want     string
+	}{
+		{
+			name: "every instance updated",
+			want: "Set 3 of 3 instances to draining:\n\n" +
+				"✔ i-0a1b2c3d4e5f60718 draining\n✔ 2 draining\n✔ i-0f1e2d3c4b5a69788 draining",
+		},
+		{
+			name: "some instances failed",
+			failures: []*ecs.Failure{
+				{Arn: awssdk.String(arn("2")), Reason: awssdk.String("MISSING")},
+				{Arn: awssdk.String(arn("3")), Reason: awssdk.String("INACTIVE"), Detail: awssdk.String("deregistered")},
+				{Reason: awssdk.String("a failure without an arn is ignored")},
+			},
+			want: "Set 1 of 3 instances to draining:\n\n" +
+				"✔ i-0a1b2c3d4e5f60718 draining\n✘ 2: MISSING\n✘ i-0f1e2d3c4b5a69788: INACTIVE (deregistered)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := describeUpdatedInstances(instances, tt.failures, "draining"); got != tt.want {
+				t.Errorf("describeUpdatedInstances() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDescribeAgentUpdates(t *testing.T) {
+	instances := aws.NewEcsContainers([]*ecs.ContainerInstance{
+		{
+			ContainerInstanceArn: awssdk.String("arn:aws:ecs:us-east-1:123456789012:container-instance/production/1"),
+			Ec2InstanceId:        awssdk.String("i-0a1b2c3d4e5f60718"),
+		},
+		{ContainerInstanceArn: awssdk.String("arn:aws:ecs:us-east-1:123456789012:container-instance/production/2")},
+	})
+	errs := []error{nil, errors.New("NoUpdateAvailableException: the agent is already running the latest version")}
+
+	want := "Started updating the ECS agent on 1 of 2 instances:\n\n" +
+		"✔ i-0a1b2c3d4e5f60718\n✘ 2: NoUpdateAvailableException: the agent is already running the latest version\n\n" +
+		"The ECS Agent column shows each update's progress."
+	if got := describeAgentUpdates(instances, errs); got != want {
+		t.Errorf("describeAgentUpdates() = %q, want %q", got, want)
+	}
+}
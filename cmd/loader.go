@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/swartzrock/ecsview/cmd/aws"
+	"github.com/swartzrock/ecsview/cmd/ecsview"
+)
+
+var spinnerFrames = []rune("⠋⠙⠹⠸⠼⠴⠦⠧⠇⠏")
+
+// A load of AWS data running in the background. Only accessed from the UI goroutine.
+type backgroundLoad struct {
+	what    string
+	cluster *aws.EcsCluster
+	phase   string
+	frame   int
+	cancel  context.CancelFunc
+}
+
+// The load in progress, if any. Starting a new load cancels it.
+var currentLoad *backgroundLoad
+
+// Run the load function on a background goroutine, showing its progress in the footer, then run done on the UI
+// goroutine. The cluster is the one being loaded, or nil if the load isn't for a single cluster.
+// Any load already in progress is cancelled, and done is never called for a cancelled load.
+func startLoad(what string, cluster *aws.EcsCluster, load func(ctx context.Context, progress ecsview.ProgressFunc), done func()) {
+	cancelLoad()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	l := &backgroundLoad{what: what, cluster: cluster, cancel: cancel}
+	currentLoad = l
+	showLoadProgress(l)
+
+	progress := func(phase string) {
+		tviewApp.QueueUpdateDraw(func() {
+			if currentLoad == l {
+				l.phase = phase
+				showLoadProgress(l)
+			}
+		})
+	}
+
+	go animateLoad(ctx, l)
+	go func() {
+		load(ctx, progress)
+		tviewApp.QueueUpdateDraw(func() {
+			if currentLoad == l {
+				currentLoad = nil
+				cancel()
+				done()
+			}
+		})
+	}()
+}
+
+// Cancel the load in progress, if any
+func cancelLoad() {
+	if currentLoad != nil {
+		currentLoad.cancel()
+		currentLoad = nil
+	}
+}
+
+// Cancel the load in progress if it's loading a cluster other than the given one
+func cancelLoadOfOtherCluster(cluster *aws.EcsCluster) {
+	if currentLoad != nil && currentLoad.cluster != nil && currentLoad.cluster != cluster {
+		cancelLoad()
+	}
+}
+
+// Advance the load's spinner until the load completes or is cancelled
+func animateLoad(ctx context.Context, l *backgroundLoad) {
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			tviewApp.QueueUpdateDraw(func() {
+				if currentLoad == l {
+					l.frame++
+					showLoadProgress(l)
+				}
+			})
+		}
+	}
+}
+
+// Show the load's spinner and current phase in the progress footer bar
+func showLoadProgress(l *backgroundLoad) {
+	progressFooterBar.Clear()
+	spinner := spinnerFrames[l.frame%len(spinnerFrames)]
+	if l.phase == "" {
+		fmt.Fprintf(progressFooterBar, "[yellow]%c[-] Loading %s…", spinner, l.what)
+	} else {
+		fmt.Fprintf(progressFooterBar, "[yellow]%c[-] Loading %s: %s…", spinner, l.what, l.phase)
+	}
+}
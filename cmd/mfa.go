@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"unicode"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+
+	"github.com/swartzrock/ecsview/cmd/ui"
+)
+
+const mfaPromptName = "mfa"
+
+// Only one MFA prompt is shown at a time
+var mfaPromptMutex sync.Mutex
+
+// Returns an MFA token provider that prompts for the token in a popup. The provider is called by the AWS SDK from a
+// background load, and waits there until the user enters a token or cancels.
+func newMfaTokenPrompter(what string) func() (string, error) {
+	return func() (string, error) {
+		mfaPromptMutex.Lock()
+		defer mfaPromptMutex.Unlock()
+
+		tokens := make(chan string, 1)
+		tviewApp.QueueUpdateDraw(func() {
+			showMfaPrompt(what, tokens)
+		})
+
+		token := <-tokens
+		if token == "" {
+			return "", errors.New("MFA token entry was cancelled")
+		}
+		return token, nil
+	}
+}
+
+// Show a popup asking for an MFA token, which is sent to the channel. An empty token is sent if the user cancels.
+func showMfaPrompt(what string, tokens chan<- string) {
+
+	form := tview.NewForm()
+	input := tview.NewInputField().
+		SetLabel("MFA token").
+		SetFieldWidth(8).
+		SetAcceptanceFunc(func(text string, lastChar rune) bool {
+			return len(text) <= 6 && unicode.IsDigit(lastChar)
+		})
+
+	answer := func(token string) {
+		rootPages.RemovePage(mfaPromptName)
+		tokens <- token
+	}
+	submit := func() {
+		if input.GetText() != "" {
+			answer(input.GetText())
+		}
+	}
+	input.SetDoneFunc(func(key tcell.Key) {
+		if key == tcell.KeyEnter {
+			submit()
+		}
+	})
+
+	form.
+		AddFormItem(input).
+		AddButton("OK", submit).
+		AddButton("Cancel", func() { answer("") }).
+		SetCancelFunc(func() { answer("") })
+	form.
+		SetBorder(true).
+		SetTitle(fmt.Sprintf(" 🔐 MFA for %s ", what)).
+		SetBorderColor(tcell.ColorGoldenrod)
+
+	rootPages.AddPage(mfaPromptName, ui.Centered(form, 50, 7), true, true)
+}
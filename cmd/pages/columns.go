@@ -0,0 +1,332 @@
+package pages
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/service/ecs"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/thoas/go-funk"
+
+	"github.com/swartzrock/ecsview/cmd/config"
+	"github.com/swartzrock/ecsview/cmd/ecsview"
+	"github.com/swartzrock/ecsview/cmd/ui"
+	"github.com/swartzrock/ecsview/cmd/utils"
+)
+
+// A column that a cluster details page can show, with its default layout
+type columnSpec struct {
+	name      string
+	alignment int
+	expansion int
+
+	// The most characters shown in the column, or in each item of a column that lists several, or 0 for no limit
+	width        int
+	itemWidth    int
+	truncateLeft bool
+
+	// The style of the column's cells, if not the default
+	style *tcell.Style
+
+	// The arrow shown in the header if the rows are rendered sorted by this column, eg "▾"
+	sorted string
+
+	// True if the column is only shown when it's configured
+	extra bool
+
+	// Returns the text of the column in a row, which is the page's row type
+	text func(row interface{}, c *column) string
+
+	// Returns a time or number to sort the column by instead of its text, if it has one
+	sortValue func(row interface{}) interface{}
+}
+
+// A column as it's shown on a page, with the user's config applied
+type column struct {
+	columnSpec
+	title string
+	tag   string
+
+	// True if the column is printed as plain text rather than shown on a page, so values aren't truncated and usage is
+	// a percentage rather than a meter
+	plain bool
+}
+
+// Returns the rows of a page for the cluster data, and the id of each row for its detail view
+type rowsFunc func(ecsData *ecsview.ClusterData) ([]interface{}, []string)
+
+// The columns a cluster details page can show, and how to make its rows
+type columnsPage struct {
+	specs []columnSpec
+	rows  rowsFunc
+}
+
+// The cluster details pages with columns, keyed by the page name in lower case
+var columnsPages = map[string]columnsPage{
+	"services":      {serviceColumnSpecs, serviceRows},
+	"tasks":         {taskColumnSpecs, runningTaskRows},
+	"instances":     {instanceColumnSpecs, instanceRows},
+	"stopped-tasks": {stoppedTaskColumnSpecs, stoppedTaskRows},
+	"task-defs":     {taskDefColumnSpecs, taskDefRows},
+}
+
+var boldColumnStyle = tcell.StyleDefault.Bold(true).Foreground(tcell.ColorWhite)
+var usageMeterStyle = tcell.StyleDefault.Foreground(tcell.ColorDarkCyan)
+
+// Returns an error if the config has a page or column that doesn't exist, or a column setting that isn't valid
+func CheckConfig(cfg *config.Config) error {
+	for page := range cfg.Pages {
+		columnsPage, found := columnsPages[page]
+		if !found {
+			pageNames := funk.Keys(columnsPages).([]string)
+			sort.Strings(pageNames)
+			return fmt.Errorf("unknown page %q in the config, choose from: %s", page, strings.Join(pageNames, ", "))
+		}
+		if _, err := newColumns(page, columnsPage.specs, cfg.Columns(page)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Returns the columns configured for the page, or its default columns if none are
+func newColumns(page string, specs []columnSpec, configured []config.Column) ([]*column, error) {
+	columns := make([]*column, 0)
+	if len(configured) == 0 {
+		for _, spec := range specs {
+			if !spec.extra {
+				columns = append(columns, &column{columnSpec: spec, title: spec.name})
+			}
+		}
+		return columns, nil
+	}
+
+	for _, settings := range configured {
+		spec, found := findColumnSpec(specs, settings.Name)
+		if !found {
+			names := funk.Map(specs, func(spec columnSpec) string { return spec.name }).([]string)
+			return nil, fmt.Errorf("unknown column %q on the %s page, choose from: %s", settings.Name, page, strings.Join(names, ", "))
+		}
+		c, err := configureColumn(spec, settings)
+		if err != nil {
+			return nil, fmt.Errorf("column %q on the %s page: %v", settings.Name, page, err)
+		}
+		columns = append(columns, c)
+	}
+	return columns, nil
+}
+
+// Returns the spec of the column with the given name, ignoring case
+func findColumnSpec(specs []columnSpec, name string) (columnSpec, bool) {
+	for _, spec := range specs {
+		if strings.EqualFold(spec.name, name) {
+			return spec, true
+		}
+	}
+	return columnSpec{}, false
+}
+
+// Returns the column with the settings applied to its default layout
+func configureColumn(spec columnSpec, settings config.Column) (*column, error) {
+	c := &column{columnSpec: spec, title: spec.name, tag: settings.Tag}
+
+	if spec.name == tagColumnName && settings.Tag == "" {
+		return nil, fmt.Errorf("a tag key is required, eg \"tag: team\"")
+	}
+	if spec.name == tagColumnName {
+		c.title = settings.Tag
+	}
+	if settings.Title != "" {
+		c.title = settings.Title
+	}
+
+	if settings.Width != nil {
+		c.width = *settings.Width
+	}
+	if settings.ItemWidth != nil {
+		c.itemWidth = *settings.ItemWidth
+	}
+	if settings.Expansion != nil {
+		c.expansion = *settings.Expansion
+	}
+
+	switch strings.ToLower(settings.Truncate) {
+	case "":
+	case "right":
+		c.truncateLeft = false
+	case "left":
+		c.truncateLeft = true
+	default:
+		return nil, fmt.Errorf("truncate must be \"left\" or \"right\", not %q", settings.Truncate)
+	}
+
+	alignments := map[string]int{"left": ui.L, "center": ui.C, "right": ui.R}
+	if settings.Align != "" {
+		alignment, found := alignments[strings.ToLower(settings.Align)]
+		if !found {
+			return nil, fmt.Errorf("align must be \"left\", \"center\" or \"right\", not %q", settings.Align)
+		}
+		c.alignment = alignment
+	}
+
+	if c.width < 0 || c.itemWidth < 0 || c.expansion < 0 {
+		return nil, fmt.Errorf("width, item-width and expansion can't be negative")
+	}
+	return c, nil
+}
+
+// Returns the text cut to the column's width
+func (c *column) truncate(text string) string {
+	if c.width == 0 || c.plain {
+		return text
+	}
+	if c.truncateLeft {
+		return utils.TakeRight(text, c.width)
+	}
+	return utils.TakeLeft(text, c.width)
+}
+
+// Returns the items cut to the column's item width and joined with commas
+func (c *column) joinItems(items []string) string {
+	if c.itemWidth > 0 && !c.plain {
+		items = funk.Map(items, func(item string) string { return utils.TakeLeft(item, c.itemWidth) }).([]string)
+	}
+	return strings.Join(items, ",")
+}
+
+// Returns the info for a page's table with a row number column followed by the given columns
+func newColumnsTable(title string, columns []*column) *ui.TableInfo {
+	table := tview.NewTable()
+	table.
+		SetBorders(true).
+		SetBorder(true).
+		SetTitle(title)
+
+	headers := []string{"#"}
+	tableInfo := &ui.TableInfo{
+		Table:      table,
+		Alignment:  []int{ui.L},
+		Expansions: []int{1},
+		Selectable: true,
+	}
+	for _, c := range columns {
+		header := c.title
+		if c.sorted != "" {
+			header = fmt.Sprintf("%s %s", header, c.sorted)
+		}
+		headers = append(headers, header)
+		tableInfo.Alignment = append(tableInfo.Alignment, c.alignment)
+		tableInfo.Expansions = append(tableInfo.Expansions, c.expansion)
+	}
+	ui.AddTableConfigData(tableInfo, 0, [][]string{headers}, tcell.ColorYellow)
+	return tableInfo
+}
+
+// Returns the titles of a page's configured columns, and the plain text of the columns in each of the page's rows, eg
+// to print them. The page name is in lower case, eg "services".
+func PageText(page string, cfg *config.Config, ecsData *ecsview.ClusterData) ([]string, [][]string) {
+	columnsPage := columnsPages[page]
+	columns, _ := newColumns(page, columnsPage.specs, cfg.Columns(page))
+	for _, c := range columns {
+		c.plain = true
+	}
+	titles := funk.Map(columns, func(c *column) string { return c.title }).([]string)
+
+	rows, _ := columnsPage.rows(ecsData)
+	text := make([][]string, 0, len(rows))
+	for _, row := range rows {
+		values := make([]string, 0, len(columns))
+		for _, c := range columns {
+			values = append(values, c.text(row, c))
+		}
+		text = append(text, values)
+	}
+	return titles, text
+}
+
+// Returns a page's renderer, which renders the page's rows into its table
+func columnsPageRenderer(tableInfo *ui.TableInfo, columns []*column, rows rowsFunc) func(*ecsview.ClusterData) {
+	return func(e *ecsview.ClusterData) {
+		pageRows, ids := rows(e)
+		renderColumnsTable(tableInfo, columns, pageRows, ids)
+	}
+}
+
+// Replaces the rows under the header of a page's table, adding a reference to each row's id to column 0 for its
+// detail view, and to each column's sort value to its cells
+func renderColumnsTable(tableInfo *ui.TableInfo, columns []*column, rows []interface{}, ids []string) {
+	ui.TruncTableRows(tableInfo.Table, 1)
+
+	if len(rows) == 0 {
+		return
+	}
+
+	data := funk.Map(rows, func(row interface{}) []string {
+		return funk.Map(columns, func(c *column) string {
+			return c.truncate(c.text(row, c))
+		}).([]string)
+	}).([][]string)
+
+	data = PrependRowNumColumn(data)
+
+	ui.AddTableConfigData(tableInfo, 1, data, tcell.ColorWhite)
+	for i, c := range columns {
+		if c.style != nil {
+			ui.SetColumnStyle(tableInfo.Table, i+1, 1, *c.style)
+		}
+	}
+
+	for row, id := range ids {
+		tableInfo.Table.GetCell(row+1, 0).SetReference(id)
+		for i, c := range columns {
+			if c.sortValue == nil {
+				continue
+			}
+			if value := c.sortValue(rows[row]); value != nil {
+				tableInfo.Table.GetCell(row+1, i+1).SetReference(value)
+			}
+		}
+	}
+}
+
+// Returns the task definition's images without their repositories, or "n/a" if the task definition isn't loaded
+func formatImages(ecsData *ecsview.ClusterData, taskDefinitionArn string, c *column) string {
+	taskDef, found := ecsData.TaskDefArnLookup[taskDefinitionArn]
+	if !found {
+		return "n/a"
+	}
+	return c.joinItems(funk.Map(taskDef.ContainerDefinitions, func(d *ecs.ContainerDefinition) string {
+		return utils.RemoveAllRegex(`.*/`, *d.Image)
+	}).([]string))
+}
+
+// Returns the usage as a meter of the column's width, or as a percentage if the column is plain text
+func formatUsage(used int64, total int64, c *column) string {
+	if c.plain {
+		return fmt.Sprintf("%.0f%%", usageRatio(used, total)*100)
+	}
+	return utils.BuildAsciiMeterCurrentTotal(used, total, c.width)
+}
+
+// Returns the launch type, eg "FARGATE", or else the capacity providers, or "n/a" if there are neither
+func formatLaunchType(launchType *string, capacityProviders []string) string {
+	if launchType != nil {
+		return *launchType
+	}
+	return joinOrNA(capacityProviders)
+}
+
+// The name of the column that shows the value of a tag
+const tagColumnName = "Tag"
+
+// Returns the value of the tag with the given key, or "n/a" if there isn't one
+func tagValue(tags []*ecs.Tag, key string) string {
+	for _, tag := range tags {
+		if tag.Key != nil && *tag.Key == key {
+			return valueOrNA(tag.Value)
+		}
+	}
+	return "n/a"
+}
@@ -1,12 +1,18 @@
 package pages
 
 import (
+	"context"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
+	"github.com/thoas/go-funk"
 
 	"github.com/swartzrock/ecsview/cmd/ecsview"
 	"github.com/swartzrock/ecsview/cmd/ui"
+	"github.com/swartzrock/ecsview/cmd/utils"
 )
 
 // Represents a page that displays details about an AWS cluster
@@ -14,8 +20,29 @@ type ClusterDetailsPage struct {
 	Name      string
 	TableInfo *ui.TableInfo
 	Render    func(ecsData *ecsview.ClusterData)
+
+	// Returns a view of the row whose first cell references the given id, eg an arn, or is nil if the page has no
+	// detail view
+	Details func(id string) *DetailsView
+
+	// The rows rendered into the table, which the user can filter
+	Rows *ui.TableRows
+}
+
+// A detailed view of a single row in a cluster details page, eg one service
+type DetailsView struct {
+	View *ui.TabbedView
+
+	// Renders the row's details from the cluster data, returning false if the row is no longer in the cluster
+	Render func(ecsData *ecsview.ClusterData) bool
+
+	// Describes the view's own key commands for the footer, if it has any, eg "[white::b]Space[darkcyan::-] Select"
+	Commands string
 }
 
+// Runs load on a background goroutine, then runs done on the UI goroutine unless the view was closed
+type BackgroundFunc func(load func(ctx context.Context), done func())
+
 func (p *ClusterDetailsPage) GetTable() *tview.Table {
 	return p.TableInfo.Table
 }
@@ -28,3 +55,67 @@ func PrependRowNumColumn(data [][]string) [][]string {
 	}
 	return data
 }
+
+// Returns the string, or "n/a" if it's nil
+func valueOrNA(s *string) string {
+	if s == nil {
+		return "n/a"
+	}
+	return *s
+}
+
+// Returns the count as a string, or "n/a" if it's nil
+func formatOptionalCount(count *int64) string {
+	if count == nil {
+		return "n/a"
+	}
+	return utils.I64ToString(*count)
+}
+
+func formatStrings(values []*string) string {
+	return joinOrNA(funk.Map(values, func(s *string) string { return *s }).([]string))
+}
+
+// Formats the time with the local time zone, or returns "n/a" if it's nil
+func formatOptionalTime(when *time.Time) string {
+	if when == nil {
+		return "n/a"
+	}
+	return utils.FormatLocalDateTimeAmPmZone(*when)
+}
+
+// Returns the portion of the total used, eg to sort by a usage meter
+func usageRatio(used int64, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(used) / float64(total)
+}
+
+// Joins the values with commas, or returns "n/a" if there are none
+func joinOrNA(values []string) string {
+	if len(values) == 0 {
+		return "n/a"
+	}
+	return strings.Join(values, ", ")
+}
+
+// Returns the info for a table in a detail view tab, with the given column headers
+func newDetailsTable(headers []string, alignment []int, expansions []int) *ui.TableInfo {
+	tableInfo := &ui.TableInfo{
+		Table:      tview.NewTable().SetBorders(true),
+		Alignment:  alignment,
+		Expansions: expansions,
+		Selectable: true,
+	}
+	ui.AddTableConfigData(tableInfo, 0, [][]string{headers}, tcell.ColorYellow)
+	return tableInfo
+}
+
+// Replaces the rows under the header of a table in a detail view tab
+func renderDetailsTable(tableInfo *ui.TableInfo, data [][]string) {
+	ui.TruncTableRows(tableInfo.Table, 1)
+	if len(data) > 0 {
+		ui.AddTableConfigData(tableInfo, 1, data, tcell.ColorWhite)
+	}
+}
@@ -0,0 +1,228 @@
+package pages
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/service/ecs"
+	"github.com/thoas/go-funk"
+
+	"github.com/swartzrock/ecsview/cmd/aws"
+	"github.com/swartzrock/ecsview/cmd/ecsview"
+	"github.com/swartzrock/ecsview/cmd/ui"
+	"github.com/swartzrock/ecsview/cmd/utils"
+)
+
+// Returns a view of a container instance's health, resources, tasks, and attributes
+func NewInstanceDetails(containerInstanceArn string) *DetailsView {
+
+	overview := ui.NewFieldsView()
+	resources := newDetailsTable(
+		[]string{"Resource", "Type", "Registered", "Remaining"},
+		[]int{ui.L, ui.L, ui.R, ui.R},
+		[]int{1, 1, 3, 3})
+	tasks := newDetailsTable(
+		[]string{"Task", "TaskDef", "Status", "CPU", "Memory", "Host Ports"},
+		[]int{ui.L, ui.L, ui.L, ui.R, ui.R, ui.L},
+		[]int{1, 1, 1, 1, 1, 2})
+	attributes := newDetailsTable(
+		[]string{"Name", "Value"},
+		[]int{ui.L, ui.L},
+		[]int{2, 3})
+
+	view := ui.NewTabbedView(" 📦 ECS Instance ").
+		AddTab("Overview", overview).
+		AddTab("Resources", resources.Table).
+		AddTab("Tasks", tasks.Table).
+		AddTab("Attributes", attributes.Table)
+
+	render := func(ecsData *ecsview.ClusterData) bool {
+		instance := findContainerInstance(ecsData.Containers, containerInstanceArn)
+		if instance == nil {
+			return false
+		}
+
+		view.SetTitle(fmt.Sprintf(" 📦 ECS Instance %s ", valueOrNA(instance.Ec2InstanceId)))
+		ui.SetFields(overview, formatInstanceOverview(instance, ecsData.LatestAgentVersion))
+		renderDetailsTable(resources, formatResources(instance))
+		renderDetailsTable(tasks, formatInstanceTasks(ecsData, containerInstanceArn))
+		renderDetailsTable(attributes, formatAttributes(instance.Attributes))
+		return true
+	}
+
+	return &DetailsView{View: view, Render: render}
+}
+
+func findContainerInstance(instances []*aws.EcsContainer, containerInstanceArn string) *aws.EcsContainer {
+	for _, instance := range instances {
+		if *instance.ContainerInstanceArn == containerInstanceArn {
+			return instance
+		}
+	}
+	return nil
+}
+
+func formatInstanceOverview(instance *aws.EcsContainer, latestAgentVersion *string) [][2]string {
+
+	agentConnected := "n/a"
+	if instance.AgentConnected != nil {
+		agentConnected = "disconnected 🚫"
+		if *instance.AgentConnected {
+			agentConnected = "connected 🔗"
+		}
+	}
+
+	agentVersion, dockerVersion := "n/a", "n/a"
+	if info := instance.VersionInfo; info != nil {
+		agentVersion = valueOrNA(info.AgentVersion)
+		dockerVersion = valueOrNA(info.DockerVersion)
+		if latestAgentVersion != nil && info.AgentVersion != nil && *info.AgentVersion != *latestAgentVersion {
+			agentVersion = fmt.Sprintf("%s (latest is %s)", agentVersion, *latestAgentVersion)
+		}
+	}
+
+	return [][2]string{
+		{"Instance id", valueOrNA(instance.Ec2InstanceId)},
+		{"Arn", *instance.ContainerInstanceArn},
+		{"Instance type", valueOrNA(instance.GetAttribute("ecs.instance-type"))},
+		{"Availability zone", valueOrNA(instance.GetAttribute("ecs.availability-zone"))},
+		{"AMI", valueOrNA(instance.GetAttribute("ecs.ami-id"))},
+		{"OS", valueOrNA(instance.GetAttribute("ecs.os-type"))},
+		{"Capacity provider", valueOrNA(instance.CapacityProviderName)},
+		{"", ""},
+		{"Status", valueOrNA(instance.Status)},
+		{"Status reason", valueOrNA(instance.StatusReason)},
+		{"Agent", agentConnected},
+		{"Agent update", valueOrNA(instance.AgentUpdateStatus)},
+		{"", ""},
+		{"ECS agent", agentVersion},
+		{"Docker", dockerVersion},
+		{"Registered", formatOptionalTime(instance.RegisteredAt)},
+		{"Running tasks", formatOptionalCount(instance.RunningTasksCount)},
+		{"Pending tasks", formatOptionalCount(instance.PendingTasksCount)},
+	}
+}
+
+// Formats each registered resource next to what remains of it, including resources with nothing remaining
+func formatResources(instance *aws.EcsContainer) [][]string {
+	remaining := make(map[string]*ecs.Resource)
+	for _, r := range instance.RemainingResources {
+		remaining[*r.Name] = r
+	}
+
+	return funk.Map(instance.RegisteredResources, func(r *ecs.Resource) []string {
+		return []string{
+			*r.Name,
+			utils.LowerTitle(strings.ReplaceAll(valueOrNA(r.Type), "_", " ")),
+			formatResourceValue(r),
+			formatResourceValue(remaining[*r.Name]),
+		}
+	}).([][]string)
+}
+
+// Formats a resource's value according to its type, eg "2048" or "22,2375,2376" for a set of ports
+func formatResourceValue(r *ecs.Resource) string {
+	switch {
+	case r == nil:
+		return "n/a"
+	case r.IntegerValue != nil:
+		return utils.I64ToString(*r.IntegerValue)
+	case r.LongValue != nil:
+		return utils.I64ToString(*r.LongValue)
+	case r.DoubleValue != nil:
+		return fmt.Sprintf("%g", *r.DoubleValue)
+	case r.Type != nil && *r.Type == "STRINGSET":
+		values := funk.Map(r.StringSetValue, func(s *string) string { return *s }).([]string)
+		sort.SliceStable(values, func(i, j int) bool {
+			return lessNumeric(values[i], values[j])
+		})
+		return joinOrNA(values)
+	default:
+		return "n/a"
+	}
+}
+
+// Formats the tasks placed on the instance with the cpu and memory they reserve
+func formatInstanceTasks(ecsData *ecsview.ClusterData, containerInstanceArn string) [][]string {
+	data := make([][]string, 0)
+	for _, task := range ecsData.Tasks {
+		if task.ContainerInstanceArn == nil || *task.ContainerInstanceArn != containerInstanceArn {
+			continue
+		}
+
+		cpu, memory := valueOrNA(task.Cpu), valueOrNA(task.Memory)
+		if taskDef, found := ecsData.TaskDefArnLookup[*task.TaskDefinitionArn]; found {
+			if task.Cpu == nil {
+				cpu = sumContainerDefinitions(taskDef, func(d *ecs.ContainerDefinition) *int64 { return d.Cpu })
+			}
+			if task.Memory == nil {
+				memory = sumContainerDefinitions(taskDef, func(d *ecs.ContainerDefinition) *int64 {
+					if d.Memory != nil {
+						return d.Memory
+					}
+					return d.MemoryReservation
+				})
+			}
+		}
+
+		hostPorts := make([]string, 0)
+		for _, container := range task.Containers {
+			for _, binding := range container.NetworkBindings {
+				hostPorts = append(hostPorts, fmt.Sprintf("%d/%s", *binding.HostPort, valueOrNA(binding.Protocol)))
+			}
+		}
+
+		data = append(data, []string{
+			utils.TakeRight(utils.RemoveAllRegex(`.*/`, *task.TaskArn), 8),
+			aws.ShortenTaskDefArn(task.TaskDefinitionArn),
+			utils.LowerTitle(valueOrNA(task.LastStatus)),
+			cpu,
+			memory,
+			joinOrNA(hostPorts),
+		})
+	}
+	return data
+}
+
+// Returns the total of a value across the task definition's containers, for tasks without a task-level value
+func sumContainerDefinitions(taskDef *ecs.TaskDefinition, value func(d *ecs.ContainerDefinition) *int64) string {
+	total := int64(0)
+	for _, definition := range taskDef.ContainerDefinitions {
+		if v := value(definition); v != nil {
+			total += *v
+		}
+	}
+	return utils.I64ToString(total)
+}
+
+// Formats the attributes sorted by name, adding the target of attributes that don't apply to the instance itself
+func formatAttributes(attributes []*ecs.Attribute) [][]string {
+	data := funk.Map(attributes, func(a *ecs.Attribute) []string {
+		name := *a.Name
+		if a.TargetType != nil && a.TargetId != nil {
+			name = fmt.Sprintf("%s (%s %s)", name, *a.TargetType, *a.TargetId)
+		}
+		value := ""
+		if a.Value != nil {
+			value = *a.Value
+		}
+		return []string{name, value}
+	}).([][]string)
+
+	sort.SliceStable(data, func(i, j int) bool {
+		return data[i][0] < data[j][0]
+	})
+	return data
+}
+
+// Compares strings as numbers if they both are, eg ports, or as strings otherwise
+func lessNumeric(a, b string) bool {
+	x, errX := strconv.Atoi(a)
+	y, errY := strconv.Atoi(b)
+	if errX == nil && errY == nil {
+		return x < y
+	}
+	return a < b
+}
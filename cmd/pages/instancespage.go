@@ -6,123 +6,144 @@ import (
 
 	"github.com/swartzrock/ecsview/cmd/ecsview"
 
-	"github.com/gdamore/tcell/v2"
-	"github.com/rivo/tview"
 	"github.com/thoas/go-funk"
 
 	"github.com/swartzrock/ecsview/cmd/aws"
+	"github.com/swartzrock/ecsview/cmd/config"
 	"github.com/swartzrock/ecsview/cmd/ui"
 	"github.com/swartzrock/ecsview/cmd/utils"
 )
 
-var latestEcsAgentVersion *string
-
-// Returns a page that displays the container instances in a cluster
-func NewInstancesPage() *ClusterDetailsPage {
-
-	instancesTable := tview.NewTable()
-	instancesTable.
-		SetBorders(true).
-		SetBorder(true).
-		SetTitle(" 📦 ECS Instances ")
-
-	instancesTableInfo := &ui.TableInfo{
-		Table:      instancesTable,
-		Alignment:  []int{ui.L, ui.L, ui.L, ui.L, ui.L, ui.L, ui.L, ui.L, ui.L, ui.L},
-		Expansions: []int{1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1},
-		Selectable: true,
-	}
-	ui.AddTableConfigData(instancesTableInfo, 0, [][]string{
-		{"#", "Instance Id ▾", "Status", "Type", "ECS Agent", "Registered", "Tasks", "CPU", "Memory"},
-	}, tcell.ColorYellow)
+// A row of the instances page
+type instanceRow struct {
+	ecsData  *ecsview.ClusterData
+	instance *aws.EcsContainer
+}
 
-	return &ClusterDetailsPage{
-		"Instances",
-		instancesTableInfo,
-		instancesPageRenderer(instancesTableInfo),
-	}
+// The columns the instances page can show
+var instanceColumnSpecs = []columnSpec{
+	{name: "Instance Id", alignment: ui.L, expansion: 1, style: &boldColumnStyle, sorted: "▾",
+		text: instanceText(func(e *ecsview.ClusterData, i *aws.EcsContainer, c *column) string { return valueOrNA(i.Ec2InstanceId) })},
+	{name: "Status", alignment: ui.L, expansion: 1,
+		text: instanceText(func(e *ecsview.ClusterData, i *aws.EcsContainer, c *column) string {
+			// Show how many tasks are left to move off a draining instance
+			status := utils.LowerTitle(*i.Status)
+			if remaining := len(e.InstanceTasks(*i.ContainerInstanceArn)); *i.Status == "DRAINING" && remaining > 0 {
+				status = fmt.Sprintf("%s (%d left)", status, remaining)
+			}
+			return status
+		})},
+	{name: "Type", alignment: ui.L, expansion: 1,
+		text: instanceText(func(e *ecsview.ClusterData, i *aws.EcsContainer, c *column) string {
+			return valueOrNA(i.GetAttribute("ecs.instance-type"))
+		})},
+	{name: "ECS Agent", alignment: ui.L, expansion: 1,
+		text: instanceText(func(e *ecsview.ClusterData, i *aws.EcsContainer, c *column) string {
+			var version *string
+			if i.VersionInfo != nil {
+				version = i.VersionInfo.AgentVersion
+			}
+			agentVersion := valueOrNA(version)
+			switch {
+			case version == nil:
+			case e.LatestAgentVersion == nil:
+				agentVersion += " ❓"
+			case agentVersion == *e.LatestAgentVersion:
+				agentVersion += " ✅"
+			default:
+				agentVersion += " ⚠️"
+			}
 
+			// Follow an agent update through its stages, eg "Staging"
+			if i.AgentUpdateStatus != nil {
+				agentVersion = fmt.Sprintf("%s %s", agentVersion, utils.LowerTitle(*i.AgentUpdateStatus))
+			}
+			return agentVersion
+		})},
+	{name: "Registered", alignment: ui.L, expansion: 1,
+		text: instanceText(func(e *ecsview.ClusterData, i *aws.EcsContainer, c *column) string {
+			return utils.FormatLocalDate(*i.RegisteredAt)
+		}),
+		sortValue: func(row interface{}) interface{} { return *row.(*instanceRow).instance.RegisteredAt }},
+	{name: "Tasks", alignment: ui.L, expansion: 1, width: 44,
+		text: instanceText(func(e *ecsview.ClusterData, i *aws.EcsContainer, c *column) string {
+			taskCount := utils.I64ToString(*i.RunningTasksCount)
+			if *i.PendingTasksCount > 0 {
+				taskCount = fmt.Sprintf("%s (%d pending)", taskCount, *i.PendingTasksCount)
+			}
+			if taskCount == "0" {
+				return taskCount
+			}
+			tasks := make([]string, 0)
+			for _, task := range e.InstanceTasks(*i.ContainerInstanceArn) {
+				tasks = append(tasks, aws.ShortenTaskDefArn(task.TaskDefinitionArn))
+			}
+			return fmt.Sprintf("%s: %s", taskCount, strings.Join(tasks, ","))
+		})},
+	{name: "CPU", alignment: ui.L, expansion: 1, width: 5, style: &usageMeterStyle,
+		text: instanceText(func(e *ecsview.ClusterData, i *aws.EcsContainer, c *column) string {
+			if usage := i.GetStats(); usage != nil {
+				return formatUsage(usage.CpuUsed, usage.CpuTotal, c)
+			}
+			return ""
+		}),
+		sortValue: func(row interface{}) interface{} {
+			if usage := row.(*instanceRow).instance.GetStats(); usage != nil {
+				return usageRatio(usage.CpuUsed, usage.CpuTotal)
+			}
+			return nil
+		}},
+	{name: "Memory", alignment: ui.L, expansion: 1, width: 5, style: &usageMeterStyle,
+		text: instanceText(func(e *ecsview.ClusterData, i *aws.EcsContainer, c *column) string {
+			if usage := i.GetStats(); usage != nil {
+				return formatUsage(usage.MemoryUsed, usage.MemoryTotal, c)
+			}
+			return ""
+		}),
+		sortValue: func(row interface{}) interface{} {
+			if usage := row.(*instanceRow).instance.GetStats(); usage != nil {
+				return usageRatio(usage.MemoryUsed, usage.MemoryTotal)
+			}
+			return nil
+		}},
+	{name: "AZ", alignment: ui.L, expansion: 1, extra: true,
+		text: instanceText(func(e *ecsview.ClusterData, i *aws.EcsContainer, c *column) string {
+			return valueOrNA(i.GetAttribute("ecs.availability-zone"))
+		})},
+	{name: tagColumnName, alignment: ui.L, expansion: 1, extra: true,
+		text: instanceText(func(e *ecsview.ClusterData, i *aws.EcsContainer, c *column) string { return tagValue(i.Tags, c.tag) })},
 }
 
-func instancesPageRenderer(tableInfo *ui.TableInfo) func(*ecsview.ClusterData) {
-	return func(e *ecsview.ClusterData) {
-		renderInstancesTable(tableInfo, e)
+// Returns a column's text func for the instances page
+func instanceText(text func(ecsData *ecsview.ClusterData, instance *aws.EcsContainer, c *column) string) func(interface{}, *column) string {
+	return func(row interface{}, c *column) string {
+		r := row.(*instanceRow)
+		return text(r.ecsData, r.instance, c)
 	}
 }
 
-func renderInstancesTable(tableInfo *ui.TableInfo, ecsData *ecsview.ClusterData) {
+// Returns a page that displays the container instances in a cluster, with the configured columns
+func NewInstancesPage(cfg *config.Config) *ClusterDetailsPage {
 
-	if latestEcsAgentVersion == nil {
-		latestEcsAgentVersion, _ = aws.GetLatestECSAgentVersion()
-	}
+	columns, _ := newColumns("instances", instanceColumnSpecs, cfg.Columns("instances"))
+	instancesTableInfo := newColumnsTable(" 📦 ECS Instances ", columns)
 
-	ui.TruncTableRows(tableInfo.Table, 1)
-	if len(ecsData.Containers) == 0 {
-		return
+	return &ClusterDetailsPage{
+		"Instances",
+		instancesTableInfo,
+		columnsPageRenderer(instancesTableInfo, columns, instanceRows),
+		NewInstanceDetails,
+		ui.NewSortableTableRows(instancesTableInfo.Table),
 	}
+}
 
-	data := funk.Map(ecsData.Containers, func(instance *aws.EcsContainer) []string {
-
-		agentVersion := *instance.VersionInfo.AgentVersion
-		if latestEcsAgentVersion == nil {
-			agentVersion = agentVersion + " ❓"
-		} else if agentVersion == *latestEcsAgentVersion {
-			agentVersion = agentVersion + " ✅"
-		} else {
-			agentVersion = agentVersion + " ⚠️"
-		}
-
-		meterWidth := 5
-		cpuMeter := ""
-		memoryMeter := ""
-		usage := instance.GetStats()
-		if usage != nil {
-			cpuMeter = utils.BuildAsciiMeterCurrentTotal(usage.CpuUsed, usage.CpuTotal, meterWidth)
-			memoryMeter = utils.BuildAsciiMeterCurrentTotal(usage.MemoryUsed, usage.MemoryTotal, meterWidth)
-		}
-
-		instanceType := "n/a"
-		instanceTypeAttribute := instance.GetAttribute("ecs.instance-type")
-		if instanceTypeAttribute != nil {
-			instanceType = *instanceTypeAttribute
-		}
-
-		taskCount := utils.I64ToString(*instance.RunningTasksCount)
-		if *instance.PendingTasksCount > 0 {
-			taskCount = fmt.Sprintf("%s (%d pending)", taskCount, *instance.PendingTasksCount)
-		}
-		if taskCount != "0" {
-			tasks := make([]string, 0)
-			for _, task := range ecsData.Tasks {
-				if task.ContainerInstanceArn != nil && *task.ContainerInstanceArn == *instance.ContainerInstanceArn {
-					tasks = append(tasks, aws.ShortenTaskDefArn(task.TaskDefinitionArn))
-				}
-			}
-			instanceTasks := strings.Join(tasks, ",")
-			taskCount = fmt.Sprintf("%s: %s", taskCount, utils.TakeLeft(instanceTasks, 40))
-		}
-
-		return []string{
-			*instance.Ec2InstanceId,
-			utils.LowerTitle(*instance.Status),
-			instanceType,
-			agentVersion,
-			utils.FormatLocalDate(*instance.RegisteredAt),
-			taskCount,
-			cpuMeter,
-			memoryMeter,
-		}
-	}).([][]string)
-
-	data = PrependRowNumColumn(data)
-
-	ui.AddTableConfigData(tableInfo, 1, data, tcell.ColorWhite)
-
-	instanceIdStyle := tcell.StyleDefault.Bold(true).Foreground(tcell.ColorWhite)
-	ui.SetColumnStyle(tableInfo.Table, 1, 1, instanceIdStyle)
-
-	usageMeterStyle := tcell.StyleDefault.Foreground(tcell.ColorDarkCyan)
-	ui.SetColumnStyle(tableInfo.Table, 7, 1, usageMeterStyle)
-	ui.SetColumnStyle(tableInfo.Table, 8, 1, usageMeterStyle)
-
+// Returns the rows of the instances page and their container instance arns
+func instanceRows(ecsData *ecsview.ClusterData) ([]interface{}, []string) {
+	rows := funk.Map(ecsData.Containers, func(instance *aws.EcsContainer) interface{} {
+		return &instanceRow{ecsData, instance}
+	}).([]interface{})
+	ids := funk.Map(ecsData.Containers, func(instance *aws.EcsContainer) string {
+		return *instance.ContainerInstanceArn
+	}).([]string)
+	return rows, ids
 }
@@ -0,0 +1,196 @@
+package pages
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/service/ecs"
+	"github.com/thoas/go-funk"
+
+	"github.com/swartzrock/ecsview/cmd/ecsview"
+	"github.com/swartzrock/ecsview/cmd/ui"
+	"github.com/swartzrock/ecsview/cmd/utils"
+)
+
+// The most service events shown, which is also the most DescribeServices returns
+const maxServiceEvents = 100
+
+// Returns a view of the deployments, events, load balancers, and network and placement settings of a service
+func NewServiceDetails(serviceArn string) *DetailsView {
+
+	deployments := newDetailsTable(
+		[]string{"Status", "Rollout", "TaskDef", "Running", "Desired", "Pending", "Failed", "Created", "Updated", "Rollout Reason"},
+		[]int{ui.L, ui.L, ui.L, ui.R, ui.R, ui.R, ui.R, ui.L, ui.L, ui.L},
+		[]int{1, 1, 1, 1, 1, 1, 1, 1, 1, 3})
+	events := newDetailsTable(
+		[]string{"Time", "Message"},
+		[]int{ui.L, ui.L},
+		[]int{1, 6})
+	loadBalancers := newDetailsTable(
+		[]string{"Type", "Name", "Container", "Port"},
+		[]int{ui.L, ui.L, ui.L, ui.R},
+		[]int{1, 3, 1, 1})
+	settings := ui.NewFieldsView()
+
+	view := ui.NewTabbedView(" 📋 ECS Service ").
+		AddTab("Deployments", deployments.Table).
+		AddTab("Events", events.Table).
+		AddTab("Load Balancers", loadBalancers.Table).
+		AddTab("Network & Placement", settings)
+
+	render := func(ecsData *ecsview.ClusterData) bool {
+		service := findService(ecsData.Services, serviceArn)
+		if service == nil {
+			return false
+		}
+
+		view.SetTitle(fmt.Sprintf(" 📋 ECS Service %s ", *service.ServiceName))
+		renderDetailsTable(deployments, formatDeployments(service.Deployments))
+		renderDetailsTable(events, formatServiceEvents(service.Events))
+		renderDetailsTable(loadBalancers, formatLoadBalancers(service))
+		ui.SetFields(settings, formatServiceSettings(service))
+		return true
+	}
+
+	return &DetailsView{View: view, Render: render}
+}
+
+func findService(services []*ecs.Service, serviceArn string) *ecs.Service {
+	for _, service := range services {
+		if *service.ServiceArn == serviceArn {
+			return service
+		}
+	}
+	return nil
+}
+
+func formatDeployments(deployments []*ecs.Deployment) [][]string {
+	return funk.Map(deployments, func(d *ecs.Deployment) []string {
+		return []string{
+			utils.LowerTitle(*d.Status),
+			formatRolloutState(d.RolloutState),
+			utils.RemoveAllRegex(`.*/`, *d.TaskDefinition),
+			utils.I64ToString(*d.RunningCount),
+			utils.I64ToString(*d.DesiredCount),
+			utils.I64ToString(*d.PendingCount),
+			formatOptionalCount(d.FailedTasks),
+			utils.FormatLocalDateTimeAmPmZone(*d.CreatedAt),
+			utils.FormatLocalDateTimeAmPmZone(*d.UpdatedAt),
+			valueOrNA(d.RolloutStateReason),
+		}
+	}).([][]string)
+}
+
+// Formats a deployment rollout state for display, eg "In Progress"
+func formatRolloutState(state *string) string {
+	if state == nil {
+		return "n/a"
+	}
+	return utils.LowerTitle(strings.ReplaceAll(*state, "_", " "))
+}
+
+// Formats the most recent service events, newest first as ECS returns them
+func formatServiceEvents(events []*ecs.ServiceEvent) [][]string {
+	if len(events) > maxServiceEvents {
+		events = events[:maxServiceEvents]
+	}
+	return funk.Map(events, func(e *ecs.ServiceEvent) []string {
+		return []string{
+			utils.FormatLocalDateTimeAmPmZone(*e.CreatedAt),
+			*e.Message,
+		}
+	}).([][]string)
+}
+
+// Formats the service's load balancers, target groups, and service discovery registries
+func formatLoadBalancers(service *ecs.Service) [][]string {
+	data := make([][]string, 0)
+	for _, lb := range service.LoadBalancers {
+		if lb.TargetGroupArn != nil {
+			data = append(data, []string{"Target Group", utils.RemoveAllRegex(`.*:targetgroup/`, *lb.TargetGroupArn),
+				valueOrNA(lb.ContainerName), formatOptionalCount(lb.ContainerPort)})
+		} else {
+			data = append(data, []string{"Load Balancer", valueOrNA(lb.LoadBalancerName),
+				valueOrNA(lb.ContainerName), formatOptionalCount(lb.ContainerPort)})
+		}
+	}
+	for _, registry := range service.ServiceRegistries {
+		port := registry.ContainerPort
+		if port == nil {
+			port = registry.Port
+		}
+		data = append(data, []string{"Service Registry", utils.RemoveAllRegex(`.*:service/`, *registry.RegistryArn),
+			valueOrNA(registry.ContainerName), formatOptionalCount(port)})
+	}
+	return data
+}
+
+func formatServiceSettings(service *ecs.Service) [][2]string {
+	fields := [][2]string{
+		{"Launch type", valueOrNA(service.LaunchType)},
+		{"Platform version", valueOrNA(service.PlatformVersion)},
+		{"Scheduling strategy", valueOrNA(service.SchedulingStrategy)},
+		{"Capacity providers", formatCapacityProviderStrategy(service.CapacityProviderStrategy)},
+		{"", ""},
+	}
+
+	network := service.NetworkConfiguration
+	if network != nil && network.AwsvpcConfiguration != nil {
+		vpc := network.AwsvpcConfiguration
+		fields = append(fields,
+			[2]string{"Network mode", "awsvpc"},
+			[2]string{"Subnets", formatStrings(vpc.Subnets)},
+			[2]string{"Security groups", formatStrings(vpc.SecurityGroups)},
+			[2]string{"Assign public IP", valueOrNA(vpc.AssignPublicIp)},
+		)
+	} else {
+		fields = append(fields, [2]string{"Network mode", "n/a"})
+	}
+	fields = append(fields, [2]string{"", ""})
+
+	placementStrategy := funk.Map(service.PlacementStrategy, func(p *ecs.PlacementStrategy) string {
+		if p.Field == nil {
+			return *p.Type
+		}
+		return fmt.Sprintf("%s(%s)", *p.Type, *p.Field)
+	}).([]string)
+	placementConstraints := funk.Map(service.PlacementConstraints, func(p *ecs.PlacementConstraint) string {
+		if p.Expression == nil {
+			return *p.Type
+		}
+		return fmt.Sprintf("%s(%s)", *p.Type, *p.Expression)
+	}).([]string)
+	fields = append(fields,
+		[2]string{"Placement strategy", joinOrNA(placementStrategy)},
+		[2]string{"Placement constraints", joinOrNA(placementConstraints)},
+		[2]string{"", ""},
+	)
+
+	if config := service.DeploymentConfiguration; config != nil {
+		fields = append(fields,
+			[2]string{"Minimum healthy", fmt.Sprintf("%s%%", formatOptionalCount(config.MinimumHealthyPercent))},
+			[2]string{"Maximum", fmt.Sprintf("%s%%", formatOptionalCount(config.MaximumPercent))},
+		)
+		if breaker := config.DeploymentCircuitBreaker; breaker != nil {
+			fields = append(fields, [2]string{"Circuit breaker",
+				fmt.Sprintf("enabled %t, rollback %t", *breaker.Enable, *breaker.Rollback)})
+		}
+	}
+	healthCheckGrace := "n/a"
+	if service.HealthCheckGracePeriodSeconds != nil {
+		healthCheckGrace = fmt.Sprintf("%ds", *service.HealthCheckGracePeriodSeconds)
+	}
+	fields = append(fields,
+		[2]string{"Health check grace", healthCheckGrace},
+		[2]string{"Role", valueOrNA(service.RoleArn)},
+	)
+	return fields
+}
+
+func formatCapacityProviderStrategy(strategy []*ecs.CapacityProviderStrategyItem) string {
+	items := funk.Map(strategy, func(item *ecs.CapacityProviderStrategyItem) string {
+		return fmt.Sprintf("%s (base %s, weight %s)", *item.CapacityProvider,
+			formatOptionalCount(item.Base), formatOptionalCount(item.Weight))
+	}).([]string)
+	return joinOrNA(items)
+}
@@ -2,96 +2,102 @@ package pages
 
 import (
 	"fmt"
-	"strings"
 
 	"github.com/swartzrock/ecsview/cmd/ecsview"
 
 	"github.com/aws/aws-sdk-go/service/ecs"
-	"github.com/gdamore/tcell/v2"
-	"github.com/rivo/tview"
 	"github.com/thoas/go-funk"
 
+	"github.com/swartzrock/ecsview/cmd/config"
 	"github.com/swartzrock/ecsview/cmd/ui"
 	"github.com/swartzrock/ecsview/cmd/utils"
 )
 
-// Returns a page that displays the services in a cluster
-func NewServicesPage() *ClusterDetailsPage {
-
-	servicesTable := tview.NewTable()
+// A row of the services page
+type serviceRow struct {
+	ecsData *ecsview.ClusterData
+	service *ecs.Service
+}
 
-	servicesTable.
-		SetBorders(true).
-		SetBorder(true).
-		SetTitle(" 📋 ECS Services ")
+// The columns the services page can show
+var serviceColumnSpecs = []columnSpec{
+	{name: "Name", alignment: ui.L, expansion: 1, style: &boldColumnStyle, sorted: "▾",
+		text: serviceText(func(e *ecsview.ClusterData, s *ecs.Service, c *column) string { return *s.ServiceName })},
+	{name: "TaskDef", alignment: ui.L, expansion: 2,
+		text: serviceText(func(e *ecsview.ClusterData, s *ecs.Service, c *column) string {
+			return utils.RemoveAllRegex(`.*/`, *s.TaskDefinition)
+		})},
+	{name: "Images", alignment: ui.L, expansion: 1, itemWidth: 50,
+		text: serviceText(func(e *ecsview.ClusterData, s *ecs.Service, c *column) string {
+			return formatImages(e, *s.TaskDefinition, c)
+		})},
+	{name: "Status", alignment: ui.L, expansion: 1,
+		text: serviceText(func(e *ecsview.ClusterData, s *ecs.Service, c *column) string { return utils.LowerTitle(*s.Status) })},
+	{name: "Deployed", alignment: ui.L, expansion: 1,
+		text: serviceText(func(e *ecsview.ClusterData, s *ecs.Service, c *column) string {
+			if len(s.Deployments) == 0 || s.Deployments[0].CreatedAt == nil {
+				return "n/a"
+			}
+			return utils.FormatLocalDateTimeAmPmZone(*s.Deployments[0].CreatedAt)
+		}),
+		sortValue: func(row interface{}) interface{} {
+			if s := row.(*serviceRow).service; len(s.Deployments) > 0 && s.Deployments[0].CreatedAt != nil {
+				return *s.Deployments[0].CreatedAt
+			}
+			return nil
+		}},
+	{name: "Tasks", alignment: ui.R, expansion: 1,
+		text: serviceText(func(e *ecsview.ClusterData, s *ecs.Service, c *column) string {
+			taskCount := utils.I64ToString(*s.RunningCount)
+			if *s.PendingCount > 0 {
+				taskCount = fmt.Sprintf("%s (%d pending)", taskCount, *s.PendingCount)
+			}
+			if *s.DesiredCount != *s.RunningCount {
+				taskCount = fmt.Sprintf("%s (%d desired)", taskCount, *s.DesiredCount)
+			}
+			return taskCount
+		})},
+	{name: "Launch Type", alignment: ui.L, expansion: 1, extra: true,
+		text: serviceText(func(e *ecsview.ClusterData, s *ecs.Service, c *column) string {
+			capacityProviders := funk.Map(s.CapacityProviderStrategy, func(item *ecs.CapacityProviderStrategyItem) string {
+				return *item.CapacityProvider
+			}).([]string)
+			return formatLaunchType(s.LaunchType, capacityProviders)
+		})},
+	{name: "Platform Version", alignment: ui.L, expansion: 1, extra: true,
+		text: serviceText(func(e *ecsview.ClusterData, s *ecs.Service, c *column) string { return valueOrNA(s.PlatformVersion) })},
+	{name: tagColumnName, alignment: ui.L, expansion: 1, extra: true,
+		text: serviceText(func(e *ecsview.ClusterData, s *ecs.Service, c *column) string { return tagValue(s.Tags, c.tag) })},
+}
 
-	servicesTableInfo := &ui.TableInfo{
-		Table:      servicesTable,
-		Alignment:  []int{ui.L, ui.L, ui.L, ui.L, ui.L, ui.L, ui.R, ui.R, ui.R},
-		Expansions: []int{1, 1, 2, 1, 1, 1, 1, 1, 1, 1, 1},
-		Selectable: true,
+// Returns a column's text func for the services page
+func serviceText(text func(ecsData *ecsview.ClusterData, service *ecs.Service, c *column) string) func(interface{}, *column) string {
+	return func(row interface{}, c *column) string {
+		r := row.(*serviceRow)
+		return text(r.ecsData, r.service, c)
 	}
-	ui.AddTableConfigData(servicesTableInfo, 0, [][]string{
-		{"#", "Name ▾", "TaskDef", "Images", "Status", "Deployed", "Tasks"},
-	}, tcell.ColorYellow)
+}
+
+// Returns a page that displays the services in a cluster, with the configured columns
+func NewServicesPage(cfg *config.Config) *ClusterDetailsPage {
+
+	columns, _ := newColumns("services", serviceColumnSpecs, cfg.Columns("services"))
+	servicesTableInfo := newColumnsTable(" 📋 ECS Services ", columns)
 
 	return &ClusterDetailsPage{
 		"Services",
 		servicesTableInfo,
-		servicesPageRenderer(servicesTableInfo),
-	}
-}
-
-func servicesPageRenderer(tableInfo *ui.TableInfo) func(*ecsview.ClusterData) {
-	return func(e *ecsview.ClusterData) {
-		renderServicesTable(tableInfo, e)
+		columnsPageRenderer(servicesTableInfo, columns, serviceRows),
+		NewServiceDetails,
+		ui.NewSortableTableRows(servicesTableInfo.Table),
 	}
 }
 
-func renderServicesTable(tableInfo *ui.TableInfo, ecsData *ecsview.ClusterData) {
-	ui.TruncTableRows(tableInfo.Table, 1)
-
-	if len(ecsData.Services) == 0 {
-		return
-	}
-
-	data := funk.Map(ecsData.Services, func(service *ecs.Service) []string {
-
-		serviceImages := "n/a"
-		maxImageWidth := 50
-		if taskDef, found := ecsData.TaskDefArnLookup[*service.TaskDefinition]; found {
-			images := funk.Map(taskDef.ContainerDefinitions, func(d *ecs.ContainerDefinition) string {
-				return utils.TakeLeft(utils.RemoveAllRegex(`.*/`, *d.Image), maxImageWidth)
-			}).([]string)
-			serviceImages = strings.Join(images, ",")
-		}
-
-		deployTimeTxt := "n/a"
-		if len(service.Deployments) > 0 {
-			deployTimeTxt = utils.FormatLocalDateTimeAmPmZone(*service.Deployments[0].CreatedAt)
-		}
-
-		taskCount := utils.I64ToString(*service.RunningCount)
-		if *service.PendingCount > 0 {
-			taskCount = fmt.Sprintf("%s (%d pending)", taskCount, *service.PendingCount)
-		}
-		if *service.DesiredCount != *service.RunningCount {
-			taskCount = fmt.Sprintf("%s (%d desired)", taskCount, *service.DesiredCount)
-		}
-
-		return []string{
-			*service.ServiceName,
-			utils.RemoveAllRegex(`.*/`, *service.TaskDefinition),
-			serviceImages,
-			utils.LowerTitle(*service.Status),
-			deployTimeTxt,
-			taskCount,
-		}
-	}).([][]string)
-
-	data = PrependRowNumColumn(data)
-
-	ui.AddTableConfigData(tableInfo, 1, data, tcell.ColorWhite)
-	servicesColumnStyle := tcell.StyleDefault.Bold(true).Foreground(tcell.ColorWhite)
-	ui.SetColumnStyle(tableInfo.Table, 1, 1, servicesColumnStyle)
+// Returns the rows of the services page and their service arns
+func serviceRows(ecsData *ecsview.ClusterData) ([]interface{}, []string) {
+	rows := funk.Map(ecsData.Services, func(service *ecs.Service) interface{} {
+		return &serviceRow{ecsData, service}
+	}).([]interface{})
+	ids := funk.Map(ecsData.Services, func(service *ecs.Service) string { return *service.ServiceArn }).([]string)
+	return rows, ids
 }
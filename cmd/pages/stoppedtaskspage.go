@@ -0,0 +1,79 @@
+package pages
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/swartzrock/ecsview/cmd/ecsview"
+
+	"github.com/aws/aws-sdk-go/service/ecs"
+	"github.com/thoas/go-funk"
+
+	"github.com/swartzrock/ecsview/cmd/config"
+	"github.com/swartzrock/ecsview/cmd/ui"
+	"github.com/swartzrock/ecsview/cmd/utils"
+)
+
+// The columns the stopped tasks page can show
+var stoppedTaskColumnSpecs = []columnSpec{
+	taskDefColumnSpec(""),
+	{name: "Stopped", alignment: ui.L, expansion: 1, sorted: "▴",
+		text: taskText(func(e *ecsview.ClusterData, t *ecs.Task, c *column) string {
+			if t.StoppedAt == nil {
+				return "stopping"
+			}
+			return utils.FormatLocalDateTimeAmPmZone(*t.StoppedAt)
+		}),
+		sortValue: func(row interface{}) interface{} {
+			if t := row.(*taskRow).task; t.StoppedAt != nil {
+				return *t.StoppedAt
+			}
+			return nil
+		}},
+	{name: "Stop Code", alignment: ui.L, expansion: 1,
+		text: taskText(func(e *ecsview.ClusterData, t *ecs.Task, c *column) string { return valueOrNA(t.StopCode) })},
+	{name: "Stopped Reason", alignment: ui.L, expansion: 2,
+		text: taskText(func(e *ecsview.ClusterData, t *ecs.Task, c *column) string { return valueOrNA(t.StoppedReason) })},
+	{name: "Container Exits", alignment: ui.L, expansion: 2,
+		text: taskText(func(e *ecsview.ClusterData, t *ecs.Task, c *column) string {
+			return strings.Join(funk.Map(t.Containers, formatContainerExit).([]string), ", ")
+		})},
+	taskArnColumnSpec,
+	taskLaunchTypeColumnSpec,
+	taskPlatformVersionColumnSpec,
+	taskAvailabilityZoneColumnSpec,
+	taskTagColumnSpec,
+}
+
+// Returns a page that displays the recently stopped tasks in a cluster, most recently stopped first, with the
+// configured columns
+func NewStoppedTasksPage(cfg *config.Config) *ClusterDetailsPage {
+
+	columns, _ := newColumns("stopped-tasks", stoppedTaskColumnSpecs, cfg.Columns("stopped-tasks"))
+	stoppedTasksTableInfo := newColumnsTable(" 🛑 Stopped ECS Tasks ", columns)
+
+	return &ClusterDetailsPage{
+		"Stopped-Tasks",
+		stoppedTasksTableInfo,
+		columnsPageRenderer(stoppedTasksTableInfo, columns, stoppedTaskRows),
+		NewTaskDetails,
+		ui.NewSortableTableRows(stoppedTasksTableInfo.Table),
+	}
+}
+
+// Returns the rows of the stopped tasks page and their task arns
+func stoppedTaskRows(ecsData *ecsview.ClusterData) ([]interface{}, []string) {
+	return taskRows(ecsData, ecsData.StoppedTasks)
+}
+
+// Formats a stopped container's name, exit code, and reason, eg "web 137 (OutOfMemoryError: Container killed)"
+func formatContainerExit(container *ecs.Container) string {
+	exit := *container.Name
+	if container.ExitCode != nil {
+		exit = fmt.Sprintf("%s %d", exit, *container.ExitCode)
+	}
+	if container.Reason != nil {
+		exit = fmt.Sprintf("%s (%s)", exit, *container.Reason)
+	}
+	return exit
+}
@@ -0,0 +1,345 @@
+package pages
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/service/ecs"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/thoas/go-funk"
+
+	"github.com/swartzrock/ecsview/cmd/aws"
+	"github.com/swartzrock/ecsview/cmd/config"
+	"github.com/swartzrock/ecsview/cmd/ecsview"
+	"github.com/swartzrock/ecsview/cmd/ui"
+	"github.com/swartzrock/ecsview/cmd/utils"
+)
+
+// A row of the task definitions page
+type taskDefRow struct {
+	ecsData *ecsview.ClusterData
+	family  string
+}
+
+// The columns the task definitions page can show
+var taskDefColumnSpecs = []columnSpec{
+	{name: "Family", alignment: ui.L, expansion: 2, style: &boldColumnStyle, sorted: "▾",
+		text: taskDefText(func(e *ecsview.ClusterData, family string, c *column) string { return family })},
+	{name: "Revisions In Use", alignment: ui.L, expansion: 2,
+		text: taskDefText(func(e *ecsview.ClusterData, family string, c *column) string {
+			revisions := familyRevisionsInUse(e, family)
+			return joinOrNA(funk.Map(revisions, func(arn string) string { return aws.ShortenTaskDefArn(&arn) }).([]string))
+		})},
+	{name: "Services", alignment: ui.L, expansion: 2,
+		text: taskDefText(func(e *ecsview.ClusterData, family string, c *column) string {
+			return joinOrNA(funk.Map(servicesUsingFamily(e, family), func(s *ecs.Service) string {
+				return *s.ServiceName
+			}).([]string))
+		})},
+	{name: "Tasks", alignment: ui.R, expansion: 1,
+		text: taskDefText(func(e *ecsview.ClusterData, family string, c *column) string {
+			return utils.I64ToString(int64(len(tasksUsingFamily(e, family))))
+		})},
+}
+
+// Returns a column's text func for the task definitions page
+func taskDefText(text func(ecsData *ecsview.ClusterData, family string, c *column) string) func(interface{}, *column) string {
+	return func(row interface{}, c *column) string {
+		r := row.(*taskDefRow)
+		return text(r.ecsData, r.family, c)
+	}
+}
+
+// Returns a page that lists the task definition families in a cluster's account and region, with the configured
+// columns. Each family's detail view loads its revisions in the background.
+func NewTaskDefinitionsPage(cfg *config.Config, background BackgroundFunc) *ClusterDetailsPage {
+
+	columns, _ := newColumns("task-defs", taskDefColumnSpecs, cfg.Columns("task-defs"))
+	taskDefsTableInfo := newColumnsTable(" 📜 ECS Task Definitions ", columns)
+
+	return &ClusterDetailsPage{
+		"Task-Defs",
+		taskDefsTableInfo,
+		columnsPageRenderer(taskDefsTableInfo, columns, taskDefRows),
+		func(family string) *DetailsView {
+			return NewTaskDefinitionDetails(family, background)
+		},
+		ui.NewSortableTableRows(taskDefsTableInfo.Table),
+	}
+}
+
+// Returns the rows of the task definitions page and their family names
+func taskDefRows(ecsData *ecsview.ClusterData) ([]interface{}, []string) {
+	rows := funk.Map(ecsData.TaskDefFamilies, func(family string) interface{} {
+		return &taskDefRow{ecsData, family}
+	}).([]interface{})
+	return rows, ecsData.TaskDefFamilies
+}
+
+// Returns the arns of the family's revisions used by the cluster's services and running tasks, newest first
+func familyRevisionsInUse(ecsData *ecsview.ClusterData, family string) []string {
+	arns := make([]string, 0)
+	for _, service := range servicesUsingFamily(ecsData, family) {
+		arns = append(arns, *service.TaskDefinition)
+	}
+	for _, task := range tasksUsingFamily(ecsData, family) {
+		arns = append(arns, *task.TaskDefinitionArn)
+	}
+	arns = funk.UniqString(arns)
+	sort.SliceStable(arns, func(i, j int) bool {
+		return lessNumeric(utils.RemoveAllRegex(`.*:`, arns[j]), utils.RemoveAllRegex(`.*:`, arns[i]))
+	})
+	return arns
+}
+
+func servicesUsingFamily(ecsData *ecsview.ClusterData, family string) []*ecs.Service {
+	return funk.Filter(ecsData.Services, func(s *ecs.Service) bool {
+		return aws.TaskDefinitionFamily(*s.TaskDefinition) == family
+	}).([]*ecs.Service)
+}
+
+func tasksUsingFamily(ecsData *ecsview.ClusterData, family string) []*ecs.Task {
+	return funk.Filter(ecsData.Tasks, func(t *ecs.Task) bool {
+		return aws.TaskDefinitionFamily(*t.TaskDefinitionArn) == family
+	}).([]*ecs.Task)
+}
+
+// Returns a view of a task definition family's revisions, where the user can read any revision's definition and
+// compare two revisions side by side. The newest revision and the changes from the one before it are shown first.
+func NewTaskDefinitionDetails(family string, background BackgroundFunc) *DetailsView {
+
+	revisions := newDetailsTable(
+		[]string{"Revision", "Services", "Tasks", "Diff"},
+		[]int{ui.L, ui.L, ui.R, ui.C},
+		[]int{1, 3, 1, 1})
+	revisions.Table.SetSelectable(true, false).SetFixed(1, 0)
+	for column := 0; column < revisions.Table.GetColumnCount(); column++ {
+		revisions.Table.GetCell(0, column).SetSelectable(false)
+	}
+
+	definition := tview.NewTextView().SetDynamicColors(true)
+	definition.SetBorderPadding(0, 0, 1, 1)
+
+	diff := newDetailsTable([]string{"", ""}, []int{ui.L, ui.L}, []int{1, 1})
+	diff.Table.SetBorders(false).SetFixed(1, 0)
+
+	view := ui.NewTabbedView(fmt.Sprintf(" 📜 ECS Task Definition %s ", family)).
+		AddTab("Revisions", revisions.Table).
+		AddTab("Definition", definition).
+		AddTab("Diff", diff.Table)
+
+	var cluster *aws.EcsCluster
+	var latestData *ecsview.ClusterData
+	var revisionArns []string
+	var revisionsErr error
+	var shownArn string
+	diffArns := make([]string, 0)
+
+	renderRevisions := func() {
+		if revisionsErr != nil {
+			renderDetailsTable(revisions, [][]string{{fmt.Sprintf("Unable to load revisions: %s", revisionsErr), "", "", ""}})
+			return
+		}
+		if revisionArns == nil {
+			renderDetailsTable(revisions, [][]string{{"Loading…", "", "", ""}})
+			return
+		}
+		renderDetailsTable(revisions, funk.Map(revisionArns, func(arn string) []string {
+			services := make([]string, 0)
+			for _, service := range latestData.Services {
+				if *service.TaskDefinition == arn {
+					services = append(services, *service.ServiceName)
+				}
+			}
+			tasks := funk.Filter(latestData.Tasks, func(t *ecs.Task) bool { return *t.TaskDefinitionArn == arn }).([]*ecs.Task)
+
+			marker := ""
+			if index := funk.IndexOfString(diffArns, arn); index >= 0 {
+				marker = []string{"◀ from", "▶ to"}[index]
+			}
+			return []string{aws.ShortenTaskDefArn(&arn), joinOrNA(services), utils.I64ToString(int64(len(tasks))), marker}
+		}).([][]string))
+		for row, arn := range revisionArns {
+			revisions.Table.GetCell(row+1, 0).SetReference(arn)
+		}
+	}
+
+	// Loads the task definitions in the background, then passes them to done in the same order
+	loadTaskDefinitions := func(arns []string, done func(taskDefs []*ecs.TaskDefinition, err error)) {
+		taskDefs := make([]*ecs.TaskDefinition, len(arns))
+		var err error
+		background(func(ctx context.Context) {
+			for i, arn := range arns {
+				if taskDefs[i], err = ecsview.GetTaskDefinition(ctx, cluster, arn); err != nil {
+					return
+				}
+			}
+		}, func() {
+			done(taskDefs, err)
+		})
+	}
+
+	showDefinition := func(arn string) {
+		shownArn = arn
+		definition.SetText(fmt.Sprintf("[yellow]%s[-]\n\nLoading…", aws.ShortenTaskDefArn(&arn)))
+		loadTaskDefinitions([]string{arn}, func(taskDefs []*ecs.TaskDefinition, err error) {
+			if shownArn != arn {
+				return
+			}
+			text := ""
+			if err == nil {
+				text, err = utils.PrettyJSON(taskDefs[0])
+			}
+			if err != nil {
+				text = fmt.Sprintf("Unable to load the task definition: %s", err)
+			}
+			definition.SetText(fmt.Sprintf("[yellow]%s[-]\n\n%s", aws.ShortenTaskDefArn(&arn), tview.Escape(text)))
+			definition.ScrollToBeginning()
+		})
+	}
+
+	showDiff := func(from string, to string) {
+		diffArns = []string{from, to}
+		renderRevisions()
+		renderDiffHeader(diff, from, to)
+		renderDetailsTable(diff, [][]string{{"Loading…", ""}})
+		loadTaskDefinitions(diffArns, func(taskDefs []*ecs.TaskDefinition, err error) {
+			if len(diffArns) != 2 || diffArns[0] != from || diffArns[1] != to {
+				return
+			}
+			if err != nil {
+				renderDetailsTable(diff, [][]string{{fmt.Sprintf("Unable to load the task definitions: %s", err), ""}})
+				return
+			}
+			renderDiff(diff, taskDefs[0], taskDefs[1])
+		})
+	}
+
+	loadRevisions := func() {
+		var arns []string
+		background(func(ctx context.Context) {
+			arns, revisionsErr = ecsview.GetTaskDefinitionRevisions(ctx, cluster, family)
+		}, func() {
+			revisionArns = arns
+			if revisionArns == nil {
+				revisionArns = make([]string, 0)
+			}
+			renderRevisions()
+			if len(revisionArns) > 0 {
+				showDefinition(revisionArns[0])
+			}
+			if len(revisionArns) > 1 {
+				showDiff(revisionArns[1], revisionArns[0])
+			}
+		})
+	}
+
+	// Enter shows a revision's definition, and Space marks revisions to compare
+	revisions.Table.SetSelectedFunc(func(row, column int) {
+		if arn, ok := revisions.Table.GetCell(row, 0).GetReference().(string); ok {
+			showDefinition(arn)
+			view.SelectTab(1)
+		}
+	})
+	revisions.Table.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() != tcell.KeyRune || event.Rune() != ' ' {
+			return event
+		}
+		row, _ := revisions.Table.GetSelection()
+		arn, ok := revisions.Table.GetCell(row, 0).GetReference().(string)
+		if !ok {
+			return nil
+		}
+		if len(diffArns) != 1 {
+			diffArns = []string{arn}
+			renderRevisions()
+		} else if diffArns[0] != arn {
+			showDiff(diffArns[0], arn)
+			view.SelectTab(2)
+		}
+		return nil
+	})
+
+	render := func(ecsData *ecsview.ClusterData) bool {
+		latestData = ecsData
+		if cluster == nil {
+			cluster = ecsData.Cluster
+			loadRevisions()
+		}
+		renderRevisions()
+		return true
+	}
+
+	return &DetailsView{
+		View:     view,
+		Render:   render,
+		Commands: "[white::b]Enter[darkcyan::-] Definition [white::b]Space[darkcyan::-] Compare",
+	}
+}
+
+func renderDiffHeader(diff *ui.TableInfo, from string, to string) {
+	diff.Table.GetCell(0, 0).SetText(aws.ShortenTaskDefArn(&from))
+	diff.Table.GetCell(0, 1).SetText(aws.ShortenTaskDefArn(&to))
+}
+
+// Renders the two task definitions side by side, lining up their unchanged lines and coloring the changed ones
+func renderDiff(diff *ui.TableInfo, from *ecs.TaskDefinition, to *ecs.TaskDefinition) {
+	fromJSON, err := utils.PrettyJSON(from)
+	if err != nil {
+		renderDetailsTable(diff, [][]string{{err.Error(), ""}})
+		return
+	}
+	toJSON, err := utils.PrettyJSON(to)
+	if err != nil {
+		renderDetailsTable(diff, [][]string{{err.Error(), ""}})
+		return
+	}
+
+	lines := utils.DiffLines(strings.Split(fromJSON, "\n"), strings.Split(toJSON, "\n"))
+
+	data := make([][]string, 0, len(lines))
+	colors := make([][2]tcell.Color, 0, len(lines))
+	removed, added := make([]string, 0), make([]string, 0)
+
+	// Pair up each run of removed and added lines, leaving blanks where one side has more lines
+	flush := func() {
+		for i := 0; i < len(removed) || i < len(added); i++ {
+			row := []string{"", ""}
+			if i < len(removed) {
+				row[0] = removed[i]
+			}
+			if i < len(added) {
+				row[1] = added[i]
+			}
+			data = append(data, row)
+			colors = append(colors, [2]tcell.Color{tcell.ColorRed, tcell.ColorGreen})
+		}
+		removed, added = removed[:0], added[:0]
+	}
+	for _, line := range lines {
+		switch line.Kind {
+		case utils.LineRemoved:
+			removed = append(removed, line.Text)
+		case utils.LineAdded:
+			added = append(added, line.Text)
+		default:
+			flush()
+			data = append(data, []string{line.Text, line.Text})
+			colors = append(colors, [2]tcell.Color{tcell.ColorWhite, tcell.ColorWhite})
+		}
+	}
+	flush()
+
+	for i := range data {
+		data[i][0] = tview.Escape(data[i][0])
+		data[i][1] = tview.Escape(data[i][1])
+	}
+	renderDetailsTable(diff, data)
+	for row, rowColors := range colors {
+		diff.Table.GetCell(row+1, 0).SetTextColor(rowColors[0])
+		diff.Table.GetCell(row+1, 1).SetTextColor(rowColors[1])
+	}
+}
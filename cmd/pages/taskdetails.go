@@ -0,0 +1,174 @@
+package pages
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/service/ecs"
+	"github.com/thoas/go-funk"
+
+	"github.com/swartzrock/ecsview/cmd/ecsview"
+	"github.com/swartzrock/ecsview/cmd/ui"
+	"github.com/swartzrock/ecsview/cmd/utils"
+)
+
+// Returns a view of a task's containers, attachments, and tags. The task may be running or stopped.
+func NewTaskDetails(taskArn string) *DetailsView {
+
+	overview := ui.NewFieldsView()
+	containers := newDetailsTable(
+		[]string{"Name", "Image", "Status", "Health", "Exit Code", "CPU", "Memory", "Ports", "Addresses"},
+		[]int{ui.L, ui.L, ui.L, ui.L, ui.R, ui.R, ui.R, ui.L, ui.L},
+		[]int{1, 2, 1, 1, 1, 1, 1, 2, 2})
+	attachments := newDetailsTable(
+		[]string{"Type", "Status", "Id", "Details"},
+		[]int{ui.L, ui.L, ui.L, ui.L},
+		[]int{1, 1, 1, 4})
+	tags := newDetailsTable(
+		[]string{"Key", "Value"},
+		[]int{ui.L, ui.L},
+		[]int{1, 3})
+
+	view := ui.NewTabbedView(" 🐳 ECS Task ").
+		AddTab("Overview", overview).
+		AddTab("Containers", containers.Table).
+		AddTab("Attachments", attachments.Table).
+		AddTab("Tags", tags.Table)
+
+	render := func(ecsData *ecsview.ClusterData) bool {
+		task := findTask(ecsData, taskArn)
+		if task == nil {
+			return false
+		}
+
+		view.SetTitle(fmt.Sprintf(" 🐳 ECS Task %s ", utils.RemoveAllRegex(`.*/`, *task.TaskArn)))
+		ui.SetFields(overview, formatTaskOverview(task))
+		renderDetailsTable(containers, formatContainers(task, ecsData.TaskDefArnLookup[*task.TaskDefinitionArn]))
+		renderDetailsTable(attachments, formatAttachments(task.Attachments))
+		renderDetailsTable(tags, funk.Map(task.Tags, func(tag *ecs.Tag) []string {
+			return []string{valueOrNA(tag.Key), valueOrNA(tag.Value)}
+		}).([][]string))
+		return true
+	}
+
+	return &DetailsView{View: view, Render: render}
+}
+
+// Returns the running or stopped task with the given arn, or nil if the cluster doesn't have it
+func findTask(ecsData *ecsview.ClusterData, taskArn string) *ecs.Task {
+	for _, tasks := range [][]*ecs.Task{ecsData.Tasks, ecsData.StoppedTasks} {
+		for _, task := range tasks {
+			if *task.TaskArn == taskArn {
+				return task
+			}
+		}
+	}
+	return nil
+}
+
+func formatTaskOverview(task *ecs.Task) [][2]string {
+	return [][2]string{
+		{"Arn", *task.TaskArn},
+		{"TaskDef", utils.RemoveAllRegex(`.*/`, *task.TaskDefinitionArn)},
+		{"Group", valueOrNA(task.Group)},
+		{"Started by", valueOrNA(task.StartedBy)},
+		{"", ""},
+		{"Status", fmt.Sprintf("%s (desired %s)", valueOrNA(task.LastStatus), valueOrNA(task.DesiredStatus))},
+		{"Health", valueOrNA(task.HealthStatus)},
+		{"Connectivity", valueOrNA(task.Connectivity)},
+		{"", ""},
+		{"Launch type", valueOrNA(task.LaunchType)},
+		{"Platform version", valueOrNA(task.PlatformVersion)},
+		{"Capacity provider", valueOrNA(task.CapacityProviderName)},
+		{"Availability zone", valueOrNA(task.AvailabilityZone)},
+		{"CPU", valueOrNA(task.Cpu)},
+		{"Memory", valueOrNA(task.Memory)},
+		{"", ""},
+		{"Created", formatOptionalTime(task.CreatedAt)},
+		{"Started", formatOptionalTime(task.StartedAt)},
+		{"Stopped", formatOptionalTime(task.StoppedAt)},
+		{"Stopped reason", valueOrNA(task.StoppedReason)},
+	}
+}
+
+// Formats each of the task's containers, with the cpu and memory reserved by its container definition
+func formatContainers(task *ecs.Task, taskDef *ecs.TaskDefinition) [][]string {
+	return funk.Map(task.Containers, func(c *ecs.Container) []string {
+
+		cpu, memory := "n/a", "n/a"
+		if definition := findContainerDefinition(taskDef, *c.Name); definition != nil {
+			cpu = formatOptionalCount(definition.Cpu)
+			memory = formatContainerMemory(definition)
+		}
+
+		ports := funk.Map(c.NetworkBindings, func(b *ecs.NetworkBinding) string {
+			return fmt.Sprintf("%s:%d→%d/%s", valueOrNA(b.BindIP), *b.HostPort, *b.ContainerPort, valueOrNA(b.Protocol))
+		}).([]string)
+
+		addresses := make([]string, 0)
+		for _, eni := range c.NetworkInterfaces {
+			for _, address := range []*string{eni.PrivateIpv4Address, eni.Ipv6Address} {
+				if address != nil {
+					addresses = append(addresses, *address)
+				}
+			}
+		}
+
+		exitCode := formatOptionalCount(c.ExitCode)
+		if c.Reason != nil {
+			exitCode = fmt.Sprintf("%s (%s)", exitCode, *c.Reason)
+		}
+
+		return []string{
+			*c.Name,
+			utils.RemoveAllRegex(`.*/`, valueOrNA(c.Image)),
+			utils.LowerTitle(valueOrNA(c.LastStatus)),
+			utils.LowerTitle(valueOrNA(c.HealthStatus)),
+			exitCode,
+			cpu,
+			memory,
+			joinOrNA(ports),
+			joinOrNA(addresses),
+		}
+	}).([][]string)
+}
+
+func findContainerDefinition(taskDef *ecs.TaskDefinition, name string) *ecs.ContainerDefinition {
+	if taskDef == nil {
+		return nil
+	}
+	for _, definition := range taskDef.ContainerDefinitions {
+		if definition.Name != nil && *definition.Name == name {
+			return definition
+		}
+	}
+	return nil
+}
+
+// Formats a container's hard memory limit and soft reservation in MiB, eg "512" or "256 (soft)"
+func formatContainerMemory(definition *ecs.ContainerDefinition) string {
+	switch {
+	case definition.Memory != nil && definition.MemoryReservation != nil:
+		return fmt.Sprintf("%d (soft %d)", *definition.Memory, *definition.MemoryReservation)
+	case definition.Memory != nil:
+		return utils.I64ToString(*definition.Memory)
+	case definition.MemoryReservation != nil:
+		return fmt.Sprintf("%d (soft)", *definition.MemoryReservation)
+	default:
+		return "n/a"
+	}
+}
+
+func formatAttachments(attachments []*ecs.Attachment) [][]string {
+	return funk.Map(attachments, func(a *ecs.Attachment) []string {
+		details := funk.Map(a.Details, func(kv *ecs.KeyValuePair) string {
+			return fmt.Sprintf("%s=%s", valueOrNA(kv.Name), valueOrNA(kv.Value))
+		}).([]string)
+		return []string{
+			valueOrNA(a.Type),
+			utils.LowerTitle(valueOrNA(a.Status)),
+			valueOrNA(a.Id),
+			strings.Join(details, ", "),
+		}
+	}).([][]string)
+}
@@ -2,104 +2,132 @@ package pages
 
 import (
 	"fmt"
-	"strings"
 
 	"github.com/swartzrock/ecsview/cmd/ecsview"
 
 	"github.com/aws/aws-sdk-go/service/ecs"
-	"github.com/gdamore/tcell/v2"
-	"github.com/rivo/tview"
 	"github.com/thoas/go-funk"
 
 	"github.com/swartzrock/ecsview/cmd/aws"
+	"github.com/swartzrock/ecsview/cmd/config"
 	"github.com/swartzrock/ecsview/cmd/ui"
 	"github.com/swartzrock/ecsview/cmd/utils"
 )
 
-// Returns a page that displays the running tasks in a cluster
-func NewTasksPage() *ClusterDetailsPage {
+// A row of the tasks or stopped tasks page
+type taskRow struct {
+	ecsData *ecsview.ClusterData
+	task    *ecs.Task
 
-	tasksTable := tview.NewTable()
-	tasksTable.
-		SetBorders(true).
-		SetBorder(true).
-		SetTitle(" 🐳 ECS Tasks ")
+	// The EC2 instance ids of the cluster's container instances, keyed by the container instance arn
+	ec2InstanceIds map[string]string
+}
 
-	tasksTableInfo := &ui.TableInfo{
-		Table:      tasksTable,
-		Alignment:  []int{ui.L, ui.L, ui.L, ui.L, ui.L, ui.L, ui.L, ui.R},
-		Expansions: []int{1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1},
-		Selectable: true,
-	}
-	ui.AddTableConfigData(tasksTableInfo, 0, [][]string{
-		{"#", "TaskDef ▾", "Images", "Status", "Created", "EC2 Instance", "Arn", "Version"},
-	}, tcell.ColorYellow)
+var connectedToEmojiMap = map[string]string{"CONNECTED": "🔗", "DISCONNECTED": "🚫"}
+
+// The columns the tasks page can show
+var taskColumnSpecs = []columnSpec{
+	taskDefColumnSpec("▾"),
+	{name: "Images", alignment: ui.L, expansion: 1, itemWidth: 20,
+		text: taskText(func(e *ecsview.ClusterData, t *ecs.Task, c *column) string {
+			return formatImages(e, *t.TaskDefinitionArn, c)
+		})},
+	{name: "Status", alignment: ui.L, expansion: 1,
+		text: taskText(func(e *ecsview.ClusterData, t *ecs.Task, c *column) string {
+			connected := connectedToEmojiMap["DISCONNECTED"]
+			if t.Connectivity != nil {
+				connected = connectedToEmojiMap[*t.Connectivity]
+			}
+			return fmt.Sprintf("%s %s", utils.LowerTitle(*t.LastStatus), connected)
+		})},
+	{name: "Created", alignment: ui.L, expansion: 1,
+		text: taskText(func(e *ecsview.ClusterData, t *ecs.Task, c *column) string {
+			return utils.FormatLocalDateTimeAmPmZone(*t.CreatedAt)
+		}),
+		sortValue: func(row interface{}) interface{} { return *row.(*taskRow).task.CreatedAt }},
+	{name: "EC2 Instance", alignment: ui.L, expansion: 1,
+		text: func(row interface{}, c *column) string {
+			r := row.(*taskRow)
+			if r.task.ContainerInstanceArn == nil {
+				return "n/a"
+			}
+			return r.ec2InstanceIds[*r.task.ContainerInstanceArn]
+		}},
+	taskArnColumnSpec,
+	{name: "Version", alignment: ui.R, expansion: 1,
+		text: taskText(func(e *ecsview.ClusterData, t *ecs.Task, c *column) string { return utils.I64ToString(*t.Version) })},
+	taskLaunchTypeColumnSpec,
+	taskPlatformVersionColumnSpec,
+	taskAvailabilityZoneColumnSpec,
+	taskTagColumnSpec,
+}
 
-	return &ClusterDetailsPage{
-		"Tasks",
-		tasksTableInfo,
-		taskPageRenderer(tasksTableInfo),
-	}
+// Returns the TaskDef column of a tasks page, with the given sort arrow if the page is sorted by it
+func taskDefColumnSpec(sorted string) columnSpec {
+	return columnSpec{name: "TaskDef", alignment: ui.L, expansion: 1, style: &boldColumnStyle, sorted: sorted,
+		text: taskText(func(e *ecsview.ClusterData, t *ecs.Task, c *column) string {
+			return aws.ShortenTaskDefArn(t.TaskDefinitionArn)
+		})}
 }
 
-func taskPageRenderer(tableInfo *ui.TableInfo) func(*ecsview.ClusterData) {
-	return func(e *ecsview.ClusterData) {
-		renderTasksPage(tableInfo, e)
+// The columns that the tasks and stopped tasks pages share
+var taskArnColumnSpec = columnSpec{name: "Arn", alignment: ui.L, expansion: 1, width: 8, truncateLeft: true,
+	text: taskText(func(e *ecsview.ClusterData, t *ecs.Task, c *column) string {
+		return utils.RemoveAllRegex(`.*/`, *t.TaskArn)
+	})}
+var taskLaunchTypeColumnSpec = columnSpec{name: "Launch Type", alignment: ui.L, expansion: 1, extra: true,
+	text: taskText(func(e *ecsview.ClusterData, t *ecs.Task, c *column) string {
+		capacityProviders := make([]string, 0)
+		if t.CapacityProviderName != nil {
+			capacityProviders = append(capacityProviders, *t.CapacityProviderName)
+		}
+		return formatLaunchType(t.LaunchType, capacityProviders)
+	})}
+var taskPlatformVersionColumnSpec = columnSpec{name: "Platform Version", alignment: ui.L, expansion: 1, extra: true,
+	text: taskText(func(e *ecsview.ClusterData, t *ecs.Task, c *column) string { return valueOrNA(t.PlatformVersion) })}
+var taskAvailabilityZoneColumnSpec = columnSpec{name: "AZ", alignment: ui.L, expansion: 1, extra: true,
+	text: taskText(func(e *ecsview.ClusterData, t *ecs.Task, c *column) string { return valueOrNA(t.AvailabilityZone) })}
+var taskTagColumnSpec = columnSpec{name: tagColumnName, alignment: ui.L, expansion: 1, extra: true,
+	text: taskText(func(e *ecsview.ClusterData, t *ecs.Task, c *column) string { return tagValue(t.Tags, c.tag) })}
+
+// Returns a column's text func for the tasks or stopped tasks page
+func taskText(text func(ecsData *ecsview.ClusterData, task *ecs.Task, c *column) string) func(interface{}, *column) string {
+	return func(row interface{}, c *column) string {
+		r := row.(*taskRow)
+		return text(r.ecsData, r.task, c)
 	}
 }
 
-func renderTasksPage(tableInfo *ui.TableInfo, ecsData *ecsview.ClusterData) {
+// Returns a page that displays the running tasks in a cluster, with the configured columns
+func NewTasksPage(cfg *config.Config) *ClusterDetailsPage {
 
-	ui.TruncTableRows(tableInfo.Table, 1)
+	columns, _ := newColumns("tasks", taskColumnSpecs, cfg.Columns("tasks"))
+	tasksTableInfo := newColumnsTable(" 🐳 ECS Tasks ", columns)
 
-	if len(ecsData.Tasks) == 0 {
-		return
+	return &ClusterDetailsPage{
+		"Tasks",
+		tasksTableInfo,
+		columnsPageRenderer(tasksTableInfo, columns, runningTaskRows),
+		NewTaskDetails,
+		ui.NewSortableTableRows(tasksTableInfo.Table),
 	}
+}
 
-	arnToEc2InstanceIdMap := make(map[string]string)
+// Returns the rows of the tasks page and their task arns
+func runningTaskRows(ecsData *ecsview.ClusterData) ([]interface{}, []string) {
+	return taskRows(ecsData, ecsData.Tasks)
+}
+
+// Returns the rows of the given tasks, which are either the cluster's running or stopped tasks, and their task arns
+func taskRows(ecsData *ecsview.ClusterData, tasks []*ecs.Task) ([]interface{}, []string) {
+	ec2InstanceIds := make(map[string]string)
 	for _, instance := range ecsData.Containers {
-		arnToEc2InstanceIdMap[*instance.ContainerInstanceArn] = *instance.Ec2InstanceId
+		ec2InstanceIds[*instance.ContainerInstanceArn] = valueOrNA(instance.Ec2InstanceId)
 	}
 
-	connectedToEmojiMap := map[string]string{"CONNECTED": "🔗", "DISCONNECTED": "🚫"}
-
-	data := funk.Map(ecsData.Tasks, func(task *ecs.Task) []string {
-
-		connected := connectedToEmojiMap["DISCONNECTED"]
-		if task.Connectivity != nil {
-			connected = connectedToEmojiMap[*task.Connectivity]
-		}
-		status := fmt.Sprintf("%s %s", utils.LowerTitle(*task.LastStatus), connected)
-
-		taskImages := "n/a"
-		maxImageWidth := 20
-		if taskDef, found := ecsData.TaskDefArnLookup[*task.TaskDefinitionArn]; found {
-			images := funk.Map(taskDef.ContainerDefinitions, func(d *ecs.ContainerDefinition) string {
-				return utils.TakeLeft(utils.RemoveAllRegex(`.*/`, *d.Image), maxImageWidth)
-			}).([]string)
-			taskImages = strings.Join(images, ",")
-		}
-
-		ec2InstanceId := "n/a"
-		if task.ContainerInstanceArn != nil {
-			ec2InstanceId = arnToEc2InstanceIdMap[*task.ContainerInstanceArn]
-		}
-
-		return []string{
-			aws.ShortenTaskDefArn(task.TaskDefinitionArn),
-			taskImages,
-			status,
-			utils.FormatLocalDateTimeAmPmZone(*task.CreatedAt),
-			ec2InstanceId,
-			utils.TakeRight(utils.RemoveAllRegex(`.*/`, *task.TaskArn), 8),
-			utils.I64ToString(*task.Version),
-		}
-	}).([][]string)
-
-	data = PrependRowNumColumn(data)
-
-	ui.AddTableConfigData(tableInfo, 1, data, tcell.ColorWhite)
-	taskArnColumnStyle := tcell.StyleDefault.Bold(true).Foreground(tcell.ColorWhite)
-	ui.SetColumnStyle(tableInfo.Table, 1, 1, taskArnColumnStyle)
-
+	rows := funk.Map(tasks, func(task *ecs.Task) interface{} {
+		return &taskRow{ecsData, task, ec2InstanceIds}
+	}).([]interface{})
+	ids := funk.Map(tasks, func(task *ecs.Task) string { return *task.TaskArn }).([]string)
+	return rows, ids
 }
@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/thoas/go-funk"
+
+	"github.com/swartzrock/ecsview/cmd/aws"
+	"github.com/swartzrock/ecsview/cmd/ui"
+)
+
+const profileSelectorName = "profiles"
+
+// Show a popup listing the shared config profiles, where the user can choose which profiles' clusters to view
+func showProfileSelector() {
+
+	// With no profiles chosen, the clusters are from the AWS_PROFILE or default profile
+	active := activeProfiles
+	if len(active) == 0 {
+		active = []string{"default"}
+		if envProfile := os.Getenv("AWS_PROFILE"); envProfile != "" {
+			active = []string{envProfile}
+		}
+	}
+
+	profiles := aws.ListProfiles()
+	for _, profile := range active {
+		if !funk.ContainsString(profiles, profile) {
+			profiles = append(profiles, profile)
+		}
+	}
+
+	checklist := ui.NewChecklist(" 👤 Profiles ", profiles, active, func(chosen []string) {
+		rootPages.RemovePage(profileSelectorName)
+		activeProfiles = chosen
+		switchBackends()
+	}, func() {
+		rootPages.RemovePage(profileSelectorName)
+	})
+
+	rootPages.AddPage(profileSelectorName, ui.Centered(checklist, 40, 20), true, true)
+}
@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"context"
+	"time"
+
+	"github.com/swartzrock/ecsview/cmd/aws"
+	"github.com/swartzrock/ecsview/cmd/ecsview"
+)
+
+// The cluster data whose refresh time and age are shown in the footer, or nil if the footer shows something else
+var footerClusterData *ecsview.ClusterData
+
+// Redraw the age of the cluster data in the footer every second, unless a load is showing its progress there
+func runFooterClock() {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		tviewApp.QueueUpdateDraw(func() {
+			if currentLoad == nil && footerClusterData != nil {
+				showClusterStatus(footerClusterData)
+			}
+		})
+	}
+}
+
+// Reload the selected cluster in the background every interval, unless the user is busy with another load or a dialog
+func runAutoRefresh(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		tviewApp.QueueUpdate(func() {
+			cluster := getCurrentlySelectedCluster()
+			if cluster != nil && currentLoad == nil && !isModalShowing() {
+				autoRefreshCluster(cluster)
+			}
+		})
+	}
+}
+
+// Reload the cluster's data in the background, reporting errors in the footer rather than interrupting the user
+func autoRefreshCluster(cluster *aws.EcsCluster) {
+	startLoad(*cluster.ClusterName, cluster, func(ctx context.Context, progress ecsview.ProgressFunc) {
+		_, _ = ecsview.RefreshClusterData(ctx, cluster, progress)
+	}, func() {
+		if getCurrentlySelectedCluster() == cluster {
+			renderCurrentClusterDetailsPage()
+		}
+	})
+}
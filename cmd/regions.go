@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"sort"
+
+	"github.com/thoas/go-funk"
+
+	"github.com/swartzrock/ecsview/cmd/aws"
+	"github.com/swartzrock/ecsview/cmd/ecsview"
+	"github.com/swartzrock/ecsview/cmd/ui"
+)
+
+const regionSelectorName = "regions"
+
+// Show a popup listing the ECS regions, where the user can choose which regions' clusters to view
+func showRegionSelector() {
+
+	active := funk.UniqString(funk.FilterString(ecsview.GetRegions(), func(region string) bool {
+		return region != ""
+	}))
+
+	// Include any active regions unknown to the SDK, eg a LocalStack region
+	regions := aws.ListEcsRegions()
+	for _, region := range active {
+		if !funk.ContainsString(regions, region) {
+			regions = append(regions, region)
+		}
+	}
+	sort.Strings(regions)
+
+	checklist := ui.NewChecklist(" 🌎 Regions ", regions, active, func(chosen []string) {
+		rootPages.RemovePage(regionSelectorName)
+		activeRegions = chosen
+		switchBackends()
+	}, func() {
+		rootPages.RemovePage(regionSelectorName)
+	})
+
+	rootPages.AddPage(regionSelectorName, ui.Centered(checklist, 30, 20), true, true)
+}
+
+// View the clusters in the active profiles and regions instead of the current ones
+func switchBackends() {
+	backends, err := newBackends(appOptions, workPool, activeProfiles, activeRegions)
+	if err != nil {
+		showErrorModal(err, switchBackends)
+		return
+	}
+
+	cancelLoad()
+	ecsview.SetBackends(backends)
+	renderClusterTable(clusterTable, nil)
+	footerClusterData = nil
+	loadClusterTable()
+}
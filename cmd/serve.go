@@ -0,0 +1,246 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/swartzrock/ecsview/cmd/aws"
+	"github.com/swartzrock/ecsview/cmd/ecsview"
+	"github.com/swartzrock/ecsview/cmd/utils"
+)
+
+// A Prometheus gauge and its samples, one for each set of label values
+type gauge struct {
+	name    string
+	help    string
+	samples []gaugeSample
+}
+
+type gaugeSample struct {
+	labels []string
+	value  float64
+}
+
+// The cluster, instance and service gauges, in the order they're served
+type clusterGauges struct {
+	cpuReserved, cpuRegistered, memoryReserved, memoryRegistered, instances, loaded, refreshed *gauge
+
+	instanceCpuReserved, instanceCpuRegistered, instanceMemoryReserved, instanceMemoryRegistered, instanceTasks *gauge
+
+	serviceRunning, serviceDesired, servicePending, serviceDeployments *gauge
+}
+
+// The latest metrics in the Prometheus text format, or nil until the clusters are first loaded
+var metricsMutex sync.Mutex
+var metricsText []byte
+
+// Serves metrics about every cluster on the metrics address, reloading the clusters every interval, until it's
+// interrupted
+func runServe(ctx context.Context, options Options) error {
+	if options.Interval <= 0 {
+		return fmt.Errorf("the interval must be more than zero, not %s", options.Interval)
+	}
+
+	// Listen before the first load, so a bad or busy address fails right away
+	listener, err := net.Listen("tcp", options.MetricsAddr)
+	if err != nil {
+		return err
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", serveMetrics)
+	server := &http.Server{Handler: mux}
+	serveErrs := make(chan error, 1)
+	go func() { serveErrs <- server.Serve(listener) }()
+	fmt.Fprintf(os.Stderr, "%s  serving metrics on http://%s/metrics every %s\n",
+		utils.FormatLocalTimestamp(time.Now()), listener.Addr(), options.Interval)
+
+	ctx, cancel := withInterrupt(ctx)
+	defer cancel()
+
+	ticker := time.NewTicker(options.Interval)
+	defer ticker.Stop()
+	for {
+		refreshMetrics(ctx)
+
+		select {
+		case err := <-serveErrs:
+			return err
+		case <-ctx.Done():
+			shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancelShutdown()
+			return server.Shutdown(shutdownCtx)
+		case <-ticker.C:
+		}
+	}
+}
+
+// Serves the latest metrics, or an error until the clusters are first loaded
+func serveMetrics(w http.ResponseWriter, r *http.Request) {
+	metricsMutex.Lock()
+	text := metricsText
+	metricsMutex.Unlock()
+
+	if text == nil {
+		http.Error(w, "the clusters haven't loaded yet", http.StatusServiceUnavailable)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = w.Write(text)
+}
+
+// Reloads every cluster and replaces the served metrics. Clusters that fail to load are reported on stderr, and their
+// gauges show what did load.
+func refreshMetrics(ctx context.Context) {
+	ecsview.ClearClusters()
+	clusters, err := ecsview.GetClusters(ctx, nil)
+	if ctx.Err() != nil {
+		return
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s  unable to load the clusters: %v\n", utils.FormatLocalTimestamp(time.Now()), err)
+	}
+	if clusters == nil {
+		return
+	}
+
+	// Refresh the clusters one at a time, since each refresh already fans out its requests on the shared work pool
+	clustersData := make([]*ecsview.ClusterData, len(clusters))
+	clusterErrs := make([]error, len(clusters))
+	for i, cluster := range clusters {
+		clustersData[i], clusterErrs[i] = ecsview.RefreshClusterData(ctx, cluster, nil)
+		if ctx.Err() != nil {
+			return
+		}
+	}
+
+	gauges := newClusterGauges()
+	for i, cluster := range clusters {
+		if clusterErrs[i] != nil {
+			fmt.Fprintf(os.Stderr, "%s  %v\n", utils.FormatLocalTimestamp(time.Now()), clusterErrs[i])
+		}
+		gauges.add(cluster, clustersData[i], clusterErrs[i])
+	}
+
+	text := gauges.format()
+	metricsMutex.Lock()
+	metricsText = text
+	metricsMutex.Unlock()
+}
+
+// Returns the gauges with no samples
+func newClusterGauges() *clusterGauges {
+	return &clusterGauges{
+		cpuReserved:      &gauge{name: "ecsview_cluster_cpu_reserved_units", help: "CPU units reserved by tasks on the cluster's container instances"},
+		cpuRegistered:    &gauge{name: "ecsview_cluster_cpu_registered_units", help: "CPU units registered by the cluster's container instances"},
+		memoryReserved:   &gauge{name: "ecsview_cluster_memory_reserved_mib", help: "Memory in MiB reserved by tasks on the cluster's container instances"},
+		memoryRegistered: &gauge{name: "ecsview_cluster_memory_registered_mib", help: "Memory in MiB registered by the cluster's container instances"},
+		instances:        &gauge{name: "ecsview_cluster_container_instances", help: "Container instances registered to the cluster"},
+		loaded:           &gauge{name: "ecsview_cluster_load_success", help: "1 if all of the cluster's data loaded in the last refresh, else 0"},
+		refreshed:        &gauge{name: "ecsview_cluster_last_refresh_timestamp_seconds", help: "When the cluster's data was last loaded, in seconds since the epoch"},
+
+		instanceCpuReserved:      &gauge{name: "ecsview_instance_cpu_reserved_units", help: "CPU units reserved by tasks on the container instance"},
+		instanceCpuRegistered:    &gauge{name: "ecsview_instance_cpu_registered_units", help: "CPU units registered by the container instance"},
+		instanceMemoryReserved:   &gauge{name: "ecsview_instance_memory_reserved_mib", help: "Memory in MiB reserved by tasks on the container instance"},
+		instanceMemoryRegistered: &gauge{name: "ecsview_instance_memory_registered_mib", help: "Memory in MiB registered by the container instance"},
+		instanceTasks:            &gauge{name: "ecsview_instance_running_tasks", help: "Tasks running on the container instance"},
+
+		serviceRunning:     &gauge{name: "ecsview_service_running_tasks", help: "The service's tasks in the RUNNING state"},
+		serviceDesired:     &gauge{name: "ecsview_service_desired_tasks", help: "The number of tasks the service wants running"},
+		servicePending:     &gauge{name: "ecsview_service_pending_tasks", help: "The service's tasks in the PENDING state"},
+		serviceDeployments: &gauge{name: "ecsview_service_deployments", help: "The service's deployments, more than 1 while a deployment is rolling out"},
+	}
+}
+
+// Adds the samples for a cluster and its instances and services. The data is nil if none of it loaded.
+func (g *clusterGauges) add(cluster *aws.EcsCluster, ecsData *ecsview.ClusterData, err error) {
+	clusterLabels := []string{"cluster", *cluster.ClusterName, "account", cluster.AccountId, "region", cluster.Region}
+
+	loaded := 1.0
+	if err != nil {
+		loaded = 0
+	}
+	g.loaded.add(clusterLabels, loaded)
+	if ecsData == nil {
+		return
+	}
+	g.refreshed.add(clusterLabels, float64(ecsData.Refreshed.Unix()))
+
+	usage := aws.EcsContainerStats{}
+	for _, instance := range ecsData.Containers {
+		// Label the instance by its EC2 instance id, or by its container instance id if it has none
+		instanceId := utils.RemoveAllRegex(`.*/`, *instance.ContainerInstanceArn)
+		if instance.Ec2InstanceId != nil {
+			instanceId = *instance.Ec2InstanceId
+		}
+		instanceLabels := append(clusterLabels[:len(clusterLabels):len(clusterLabels)], "instance", instanceId)
+
+		if stats := instance.GetStats(); stats != nil {
+			usage.Add(stats)
+			g.instanceCpuReserved.add(instanceLabels, float64(stats.CpuUsed))
+			g.instanceCpuRegistered.add(instanceLabels, float64(stats.CpuTotal))
+			g.instanceMemoryReserved.add(instanceLabels, float64(stats.MemoryUsed))
+			g.instanceMemoryRegistered.add(instanceLabels, float64(stats.MemoryTotal))
+		}
+		if instance.RunningTasksCount != nil {
+			g.instanceTasks.add(instanceLabels, float64(*instance.RunningTasksCount))
+		}
+	}
+	g.cpuReserved.add(clusterLabels, float64(usage.CpuUsed))
+	g.cpuRegistered.add(clusterLabels, float64(usage.CpuTotal))
+	g.memoryReserved.add(clusterLabels, float64(usage.MemoryUsed))
+	g.memoryRegistered.add(clusterLabels, float64(usage.MemoryTotal))
+	g.instances.add(clusterLabels, float64(len(ecsData.Containers)))
+
+	for _, service := range ecsData.Services {
+		serviceLabels := append(clusterLabels[:len(clusterLabels):len(clusterLabels)], "service", *service.ServiceName)
+		g.serviceRunning.add(serviceLabels, float64(*service.RunningCount))
+		g.serviceDesired.add(serviceLabels, float64(*service.DesiredCount))
+		g.servicePending.add(serviceLabels, float64(*service.PendingCount))
+		g.serviceDeployments.add(serviceLabels, float64(len(service.Deployments)))
+	}
+}
+
+// Returns the gauges in the Prometheus text format
+func (g *clusterGauges) format() []byte {
+	var buf bytes.Buffer
+	for _, gauge := range []*gauge{
+		g.cpuReserved, g.cpuRegistered, g.memoryReserved, g.memoryRegistered, g.instances, g.loaded, g.refreshed,
+		g.instanceCpuReserved, g.instanceCpuRegistered, g.instanceMemoryReserved, g.instanceMemoryRegistered, g.instanceTasks,
+		g.serviceRunning, g.serviceDesired, g.servicePending, g.serviceDeployments,
+	} {
+		gauge.format(&buf)
+	}
+	return buf.Bytes()
+}
+
+// Adds a sample with the labels, given as pairs of names and values, eg "cluster", "production"
+func (g *gauge) add(labels []string, value float64) {
+	g.samples = append(g.samples, gaugeSample{labels, value})
+}
+
+// Writes the gauge's help, type and samples in the Prometheus text format, eg
+// `ecsview_service_running_tasks{cluster="production",service="web"} 2`
+func (g *gauge) format(buf *bytes.Buffer) {
+	fmt.Fprintf(buf, "# HELP %s %s\n", g.name, g.help)
+	fmt.Fprintf(buf, "# TYPE %s gauge\n", g.name)
+	for _, sample := range g.samples {
+		labels := make([]string, 0, len(sample.labels)/2)
+		for i := 0; i < len(sample.labels); i += 2 {
+			labels = append(labels, fmt.Sprintf(`%s="%s"`, sample.labels[i], escapeLabelValue(sample.labels[i+1])))
+		}
+		fmt.Fprintf(buf, "%s{%s} %s\n", g.name, strings.Join(labels, ","), strconv.FormatFloat(sample.value, 'f', -1, 64))
+	}
+}
+
+// Returns the label value with its backslashes, quotes and newlines escaped, minus the surrounding quotes
+func escapeLabelValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
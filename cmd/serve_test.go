@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	awssdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ecs"
+
+	"github.com/swartzrock/ecsview/cmd/aws"
+	"github.com/swartzrock/ecsview/cmd/ecsview"
+)
+
+func TestEscapeLabelValue(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"production", "production"},
+		{"", ""},
+		{`C:\ecs`, `C:\\ecs`},
+		{`say "hi"`, `say \"hi\"`},
+		{"two\nlines", `two\nlines`},
+		{"\\\"\n", `\\\"\n`},
+	}
+	for _, tt := range tests {
+		if got := escapeLabelValue(tt.value); got != tt.want {
+			t.Errorf("escapeLabelValue(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestGaugeFormat(t *testing.T) {
+	g := &gauge{name: "ecsview_service_running_tasks", help: "The service's tasks in the RUNNING state"}
+
+	var buf bytes.Buffer
+	g.format(&buf)
+	want := "# HELP ecsview_service_running_tasks The service's tasks in the RUNNING state\n" +
+		"# TYPE ecsview_service_running_tasks gauge\n"
+	if buf.String() != want {
+		t.Errorf("format() without samples = %q, want %q", buf.String(), want)
+	}
+
+	g.add([]string{"cluster", "production", "service", "web"}, 2)
+	g.add([]string{"cluster", `prod "eu"`, "service", "api"}, 0.5)
+	g.add([]string{"cluster", "production", "service", "batch"}, 1611671483)
+	buf.Reset()
+	g.format(&buf)
+	want += `ecsview_service_running_tasks{cluster="production",service="web"} 2` + "\n" +
+		`ecsview_service_running_tasks{cluster="prod \"eu\"",service="api"} 0.5` + "\n" +
+		`ecsview_service_running_tasks{cluster="production",service="batch"} 1611671483` + "\n"
+	if buf.String() != want {
+		t.Errorf("format() = %q, want %q", buf.String(), want)
+	}
+}
+
+// Returns a cluster in us-east-1 with the given name
+func newTestCluster(name string) *aws.EcsCluster {
+	return &aws.EcsCluster{
+		Cluster:   &ecs.Cluster{ClusterName: awssdk.String(name)},
+		AccountId: "123456789012",
+		Region:    "us-east-1",
+	}
+}
+
+func TestClusterGaugesAdd(t *testing.T) {
+	resources := func(cpu int64, memory int64) []*ecs.Resource {
+		return []*ecs.Resource{
+			{Name: awssdk.String("CPU"), IntegerValue: awssdk.Int64(cpu)},
+			{Name: awssdk.String("MEMORY"), IntegerValue: awssdk.Int64(memory)},
+		}
+	}
+	cluster := newTestCluster("production")
+	ecsData := &ecsview.ClusterData{
+		Cluster:   cluster,
+		Refreshed: time.Unix(1611671483, 0),
+		Containers: aws.NewEcsContainers([]*ecs.ContainerInstance{
+			{
+				ContainerInstanceArn: awssdk.String("arn:aws:ecs:us-east-1:123456789012:container-instance/production/1"),
+				Ec2InstanceId:        awssdk.String("i-0a1b2c3d4e5f60718"),
+				RegisteredResources:  resources(2048, 4096),
+				RemainingResources:   resources(1024, 3072),
+				RunningTasksCount:    awssdk.Int64(3),
+			},
+			{
+				// Fargate and external instances may have no EC2 instance id or resources
+				ContainerInstanceArn: awssdk.String("arn:aws:ecs:us-east-1:123456789012:container-instance/production/2"),
+			},
+		}),
+		Services: []*ecs.Service{{
+			ServiceName:  awssdk.String("web"),
+			RunningCount: awssdk.Int64(2),
+			DesiredCount: awssdk.Int64(3),
+			PendingCount: awssdk.Int64(1),
+			Deployments:  []*ecs.Deployment{{}, {}},
+		}},
+	}
+
+	gauges := newClusterGauges()
+	gauges.add(cluster, ecsData, nil)
+	gauges.add(newTestCluster("staging"), nil, errors.New("AccessDeniedException"))
+	text := string(gauges.format())
+
+	labels := `cluster="production",account="123456789012",region="us-east-1"`
+	for _, want := range []string{
+		`ecsview_cluster_cpu_reserved_units{` + labels + `} 1024`,
+		`ecsview_cluster_cpu_registered_units{` + labels + `} 2048`,
+		`ecsview_cluster_memory_reserved_mib{` + labels + `} 1024`,
+		`ecsview_cluster_memory_registered_mib{` + labels + `} 4096`,
+		`ecsview_cluster_container_instances{` + labels + `} 2`,
+		`ecsview_cluster_load_success{` + labels + `} 1`,
+		`ecsview_cluster_last_refresh_timestamp_seconds{` + labels + `} 1611671483`,
+		`ecsview_instance_cpu_reserved_units{` + labels + `,instance="i-0a1b2c3d4e5f60718"} 1024`,
+		`ecsview_instance_running_tasks{` + labels + `,instance="i-0a1b2c3d4e5f60718"} 3`,
+		`ecsview_service_running_tasks{` + labels + `,service="web"} 2`,
+		`ecsview_service_desired_tasks{` + labels + `,service="web"} 3`,
+		`ecsview_service_pending_tasks{` + labels + `,service="web"} 1`,
+		`ecsview_service_deployments{` + labels + `,service="web"} 2`,
+		`ecsview_cluster_load_success{cluster="staging",account="123456789012",region="us-east-1"} 0`,
+	} {
+		if !strings.Contains(text, want+"\n") {
+			t.Errorf("format() is missing %s", want)
+		}
+	}
+
+	// An instance without resources or a task count has no instance samples, and a cluster that didn't load only
+	// reports the failure
+	for _, unwanted := range []string{`instance="2"`, `cluster="staging",account="123456789012",region="us-east-1"} 1`} {
+		if strings.Contains(text, unwanted) {
+			t.Errorf("format() has a sample with %s", unwanted)
+		}
+	}
+	if got := strings.Count(text, `cluster="staging"`); got != 1 {
+		t.Errorf("format() has %d staging samples, want only the load failure", got)
+	}
+}
+
+func TestServeMetrics(t *testing.T) {
+	defer func() { metricsText = nil }()
+
+	// Nothing is served until the clusters first load
+	metricsText = nil
+	recorder := httptest.NewRecorder()
+	serveMetrics(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if recorder.Code != http.StatusServiceUnavailable {
+		t.Errorf("status before the first load = %d, want %d", recorder.Code, http.StatusServiceUnavailable)
+	}
+
+	metricsText = []byte("# TYPE ecsview_cluster_container_instances gauge\n")
+	recorder = httptest.NewRecorder()
+	serveMetrics(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if recorder.Code != http.StatusOK || recorder.Body.String() != string(metricsText) {
+		t.Errorf("serveMetrics() = %d %q, want %d %q", recorder.Code, recorder.Body.String(), http.StatusOK, metricsText)
+	}
+	if got := recorder.Header().Get("Content-Type"); !strings.HasPrefix(got, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %q, want the Prometheus text format", got)
+	}
+}
@@ -0,0 +1,268 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/aws/aws-sdk-go/service/ecs"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+
+	"github.com/swartzrock/ecsview/cmd/aws"
+	"github.com/swartzrock/ecsview/cmd/ecsview"
+	"github.com/swartzrock/ecsview/cmd/pages"
+)
+
+// The most tasks a service can be scaled to from the scale form
+const maxDesiredCount = 10000
+
+// Returns the service in the page's selected row, or nil if no service is selected
+func getSelectedService(page *pages.ClusterDetailsPage, ecsData *ecsview.ClusterData) *ecs.Service {
+	arn, ok := getSelectedRowId(page)
+	if !ok {
+		return nil
+	}
+	for _, service := range ecsData.Services {
+		if *service.ServiceArn == arn {
+			return service
+		}
+	}
+	return nil
+}
+
+// Show a form to change the selected service's desired count, then confirm and make the change
+func scaleSelectedService(page *pages.ClusterDetailsPage, cluster *aws.EcsCluster, ecsData *ecsview.ClusterData) {
+	service := getSelectedService(page, ecsData)
+	if service == nil {
+		return
+	}
+
+	form := tview.NewForm()
+	input := tview.NewInputField().
+		SetLabel("Desired tasks").
+		SetText(strconv.FormatInt(*service.DesiredCount, 10)).
+		SetFieldWidth(8).
+		SetAcceptanceFunc(func(text string, lastChar rune) bool {
+			return len(text) <= 5 && unicode.IsDigit(lastChar)
+		})
+
+	submit := func() {
+		desiredCount, err := parseDesiredCount(service, input.GetText())
+		if err != nil {
+			closeActionForm(func() { showResultModal(fmt.Sprintf("Nothing was changed: %v.", err)) })
+			return
+		}
+		closeActionForm(func() { confirmScaleService(cluster, service, desiredCount) })
+	}
+	input.SetDoneFunc(func(key tcell.Key) {
+		if key == tcell.KeyEnter {
+			submit()
+		}
+	})
+
+	form.
+		AddFormItem(input).
+		AddButton("Scale", submit).
+		AddButton("Cancel", closeActionPopup)
+	showActionForm(fmt.Sprintf(" ⚖️  Scale %s ", *service.ServiceName), form, 50, 7)
+}
+
+// Returns the desired count the user entered for the service, or an error explaining why it won't be changed
+func parseDesiredCount(service *ecs.Service, text string) (int64, error) {
+	desiredCount, err := strconv.ParseInt(text, 10, 64)
+	if err != nil || desiredCount < 0 || desiredCount > maxDesiredCount {
+		return 0, fmt.Errorf("%s can be scaled to from 0 to %d desired tasks", *service.ServiceName, maxDesiredCount)
+	}
+	if desiredCount == *service.DesiredCount {
+		return 0, fmt.Errorf("%s already has %d desired tasks", *service.ServiceName, desiredCount)
+	}
+	return desiredCount, nil
+}
+
+// Ask the user to confirm scaling the service, then scale it and show its refreshed task counts
+func confirmScaleService(cluster *aws.EcsCluster, service *ecs.Service, desiredCount int64) {
+	text := fmt.Sprintf("Scale %s in %s from %d to %d desired tasks?\n\nIt has %d running and %d pending.",
+		*service.ServiceName, *cluster.ClusterName, *service.DesiredCount, desiredCount, *service.RunningCount, *service.PendingCount)
+	showConfirmModal(text, "Scale", func() {
+		var refreshed *ecsview.ClusterData
+		runAction(fmt.Sprintf("Scaling %s to %d tasks", *service.ServiceName, desiredCount), func(ctx context.Context) error {
+			if _, err := ecsview.ScaleService(ctx, cluster, service, desiredCount); err != nil {
+				return err
+			}
+			refreshed, _ = ecsview.RefreshClusterData(ctx, cluster, nil)
+			return nil
+		}, func() {
+			renderActionResult(cluster)
+			showResultModal(describeScaledService(service, refreshed))
+		})
+	})
+}
+
+// Describes the scaled service's task counts in the refreshed cluster data
+func describeScaledService(service *ecs.Service, refreshed *ecsview.ClusterData) string {
+	if refreshed != nil {
+		for _, s := range refreshed.Services {
+			if *s.ServiceArn == *service.ServiceArn {
+				return fmt.Sprintf("%s now has %d desired tasks, with %d running and %d pending.",
+					*s.ServiceName, *s.DesiredCount, *s.RunningCount, *s.PendingCount)
+			}
+		}
+	}
+	return fmt.Sprintf("%s was scaled, but its task counts couldn't be reloaded. Press R to refresh.", *service.ServiceName)
+}
+
+// Ask the user to confirm a new deployment of the selected service with its current task definition, then start it
+// and follow its rollout
+func forceDeploySelectedService(page *pages.ClusterDetailsPage, cluster *aws.EcsCluster, ecsData *ecsview.ClusterData) {
+	service := getSelectedService(page, ecsData)
+	if service == nil {
+		return
+	}
+
+	text := fmt.Sprintf("Start a new deployment of %s in %s with %s? ECS replaces every one of its tasks, eg to pull "+
+		"a new image with the same tag.", *service.ServiceName, *cluster.ClusterName, aws.ShortenTaskDefArn(service.TaskDefinition))
+	showTypedConfirm(fmt.Sprintf(" 🚀 Force deployment of %s ", *service.ServiceName), text, *service.ServiceName, "Deploy", func() {
+		runAction(fmt.Sprintf("Starting a new deployment of %s", *service.ServiceName), func(ctx context.Context) error {
+			if _, err := ecsview.ForceNewDeployment(ctx, cluster, service); err != nil {
+				return err
+			}
+			_, _ = ecsview.RefreshClusterData(ctx, cluster, nil)
+			return nil
+		}, func() {
+			renderActionResult(cluster)
+			followRollout(page, cluster, *service.ServiceArn)
+		})
+	})
+}
+
+// Find the task definition the selected service ran before its current one, ask the user to confirm deploying it,
+// then deploy it and follow the rollout
+func rollbackSelectedService(page *pages.ClusterDetailsPage, cluster *aws.EcsCluster, ecsData *ecsview.ClusterData) {
+	service := getSelectedService(page, ecsData)
+	if service == nil {
+		return
+	}
+
+	var previous string
+	runAction(fmt.Sprintf("Finding the task definition %s ran before %s", *service.ServiceName,
+		aws.ShortenTaskDefArn(service.TaskDefinition)), func(ctx context.Context) error {
+		var err error
+		previous, err = ecsview.GetPreviousTaskDefinition(ctx, cluster, service)
+		return err
+	}, func() {
+		text := fmt.Sprintf("Roll %s in %s back from %s to %s? ECS replaces every one of its tasks with the previous "+
+			"task definition.", *service.ServiceName, *cluster.ClusterName, aws.ShortenTaskDefArn(service.TaskDefinition),
+			aws.ShortenTaskDefArn(&previous))
+		showTypedConfirm(fmt.Sprintf(" ⏪ Roll back %s ", *service.ServiceName), text, *service.ServiceName, "Roll Back", func() {
+			runAction(fmt.Sprintf("Rolling %s back to %s", *service.ServiceName, aws.ShortenTaskDefArn(&previous)), func(ctx context.Context) error {
+				if _, err := ecsview.DeployTaskDefinition(ctx, cluster, service, previous); err != nil {
+					return err
+				}
+				_, _ = ecsview.RefreshClusterData(ctx, cluster, nil)
+				return nil
+			}, func() {
+				renderActionResult(cluster)
+				followRollout(page, cluster, *service.ServiceArn)
+			})
+		})
+	})
+}
+
+// How often the rollout view reloads the cluster
+const rolloutRefreshInterval = 5 * time.Second
+
+// Open the service's detail view, which shows its deployments first, and reload the cluster every few seconds until
+// the rollout finishes or the user closes the view
+func followRollout(page *pages.ClusterDetailsPage, cluster *aws.EcsCluster, serviceArn string) {
+	if getCurrentlySelectedCluster() != cluster || getFrontClusterDetailsPage() != page {
+		return
+	}
+	closeDetails()
+	for row := 1; row < page.GetTable().GetRowCount(); row++ {
+		if page.GetTable().GetCell(row, 0).GetReference() == serviceArn {
+			page.GetTable().Select(row, 0)
+			showDetails(page, row)
+			break
+		}
+	}
+	opened := currentDetails
+	if opened == nil {
+		return
+	}
+
+	showRolloutStatus := func(status string) {
+		opened.details.Commands = status
+		if currentDetails == opened {
+			writeDetailsFooterText()
+		}
+	}
+	showRolloutStatus("[yellow]Following the rollout…[-]")
+
+	finished := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(rolloutRefreshInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-opened.ctx.Done():
+				return
+			case <-finished:
+				return
+			case <-ticker.C:
+			}
+
+			tviewApp.QueueUpdateDraw(func() {
+				if currentDetails != opened || opened.ctx.Err() != nil {
+					return
+				}
+
+				// An earlier queued update may have seen the rollout finish
+				select {
+				case <-finished:
+					return
+				default:
+				}
+
+				ecsData, _ := ecsview.GetCachedClusterData(cluster)
+				if ecsData == nil {
+					return
+				}
+				if state, done := rolloutState(ecsData, serviceArn); done {
+					showRolloutStatus(fmt.Sprintf("[white::b]Rollout %s[-::-]", state))
+					close(finished)
+					return
+				}
+				if currentLoad == nil && !rootPages.HasPage(actionPopupName) && !rootPages.HasPage(errorModalName) {
+					autoRefreshCluster(cluster)
+				}
+			})
+		}
+	}()
+}
+
+// Returns the state of the service's newest deployment, eg "In Progress", and whether its rollout has finished
+func rolloutState(ecsData *ecsview.ClusterData, serviceArn string) (string, bool) {
+	for _, service := range ecsData.Services {
+		if *service.ServiceArn != serviceArn || len(service.Deployments) == 0 {
+			continue
+		}
+		primary := service.Deployments[0]
+		for _, deployment := range service.Deployments {
+			if *deployment.Status == "PRIMARY" {
+				primary = deployment
+			}
+		}
+
+		// Deployments without a rollout state, eg by an external controller, finish when the old ones are gone
+		if primary.RolloutState == nil {
+			return "finished", len(service.Deployments) == 1
+		}
+		state := *primary.RolloutState
+		return strings.ToLower(strings.ReplaceAll(state, "_", " ")), state != ecs.DeploymentRolloutStateInProgress
+	}
+	return "finished", true
+}
@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"testing"
+
+	awssdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ecs"
+)
+
+func TestParseDesiredCount(t *testing.T) {
+	service := &ecs.Service{ServiceName: awssdk.String("web"), DesiredCount: awssdk.Int64(2)}
+	tests := []struct {
+		name    string
+		text    string
+		want    int64
+		wantErr string
+	}{
+		{"scale up", "5", 5, ""},
+		{"scale to zero", "0", 0, ""},
+		{"the most tasks", "10000", 10000, ""},
+		{"unchanged", "2", 0, "web already has 2 desired tasks"},
+		{"leading zeros", "02", 0, "web already has 2 desired tasks"},
+		{"empty", "", 0, "web can be scaled to from 0 to 10000 desired tasks"},
+		{"too many", "10001", 0, "web can be scaled to from 0 to 10000 desired tasks"},
+		{"negative", "-1", 0, "web can be scaled to from 0 to 10000 desired tasks"},
+		{"not a number", "five", 0, "web can be scaled to from 0 to 10000 desired tasks"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseDesiredCount(service, tt.text)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("parseDesiredCount(%q) error = %v, want %q", tt.text, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("parseDesiredCount(%q) = %d, %v, want %d", tt.text, got, err, tt.want)
+			}
+		})
+	}
+}
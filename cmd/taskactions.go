@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/service/ecs"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+
+	"github.com/swartzrock/ecsview/cmd/aws"
+	"github.com/swartzrock/ecsview/cmd/ecsview"
+	"github.com/swartzrock/ecsview/cmd/pages"
+	"github.com/swartzrock/ecsview/cmd/utils"
+)
+
+// The reason the stop form suggests, which ECS shows with the stopped tasks
+const defaultStopReason = "Stopped with ecsview"
+
+// Returns the tasks in the page's marked rows, or in its selected row if none are marked
+func getMarkedTasks(page *pages.ClusterDetailsPage, ecsData *ecsview.ClusterData) []*ecs.Task {
+	tasks := make([]*ecs.Task, 0)
+	for _, arn := range getMarkedRowIds(page) {
+		for _, task := range ecsData.Tasks {
+			if *task.TaskArn == arn {
+				tasks = append(tasks, task)
+			}
+		}
+	}
+	return tasks
+}
+
+// Show a form asking why the marked or selected tasks are being stopped, then confirm and stop them
+func stopMarkedTasks(page *pages.ClusterDetailsPage, cluster *aws.EcsCluster, ecsData *ecsview.ClusterData) {
+	tasks := getMarkedTasks(page, ecsData)
+	if len(tasks) == 0 {
+		return
+	}
+
+	form := tview.NewForm()
+	input := tview.NewInputField().
+		SetLabel("Reason").
+		SetText(defaultStopReason).
+		SetFieldWidth(40)
+
+	submit := func() {
+		reason := strings.TrimSpace(input.GetText())
+		if reason == "" {
+			return
+		}
+		closeActionForm(func() { confirmStopTasks(page, cluster, tasks, reason) })
+	}
+	input.SetDoneFunc(func(key tcell.Key) {
+		if key == tcell.KeyEnter {
+			submit()
+		}
+	})
+
+	form.
+		AddFormItem(input).
+		AddButton("Stop", submit).
+		AddButton("Cancel", closeActionPopup)
+	showActionForm(fmt.Sprintf(" 🛑 Stop %s ", describeTaskCount(len(tasks))), form, 60, 7)
+}
+
+// Ask the user to confirm stopping the tasks, then stop them and show which stopped and which failed to
+func confirmStopTasks(page *pages.ClusterDetailsPage, cluster *aws.EcsCluster, tasks []*ecs.Task, reason string) {
+	lines := make([]string, 0, len(tasks))
+	for _, task := range tasks {
+		lines = append(lines, describeTask(task))
+	}
+	text := fmt.Sprintf("Stop %s in %s?\n\n%s\n\nReason: %s", describeTaskCount(len(tasks)), *cluster.ClusterName,
+		strings.Join(lines, "\n"), reason)
+
+	showConfirmModal(text, "Stop", func() {
+		var errs []error
+		runAction(fmt.Sprintf("Stopping %s", describeTaskCount(len(tasks))), func(ctx context.Context) error {
+			// Stop every task even if some fail, so the user sees what happened to each of them
+			errs = workPool.RunEach(ctx, len(tasks), func(ctx context.Context, i int) error {
+				_, err := ecsview.StopTask(ctx, cluster, tasks[i], reason)
+				return err
+			})
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			_, _ = ecsview.RefreshClusterData(ctx, cluster, nil)
+			return nil
+		}, func() {
+			page.Rows.ClearMarks()
+			renderActionResult(cluster)
+			showResultModal(describeStoppedTasks(tasks, errs))
+		})
+	})
+}
+
+// Describes which of the tasks stopped and why the others didn't
+func describeStoppedTasks(tasks []*ecs.Task, errs []error) string {
+	stopped := 0
+	lines := make([]string, 0, len(tasks))
+	for i, task := range tasks {
+		if errs[i] != nil {
+			lines = append(lines, fmt.Sprintf("✘ %s: %v", describeTask(task), errs[i]))
+		} else {
+			stopped++
+			lines = append(lines, fmt.Sprintf("✔ %s", describeTask(task)))
+		}
+	}
+	return fmt.Sprintf("Stopped %d of %s:\n\n%s", stopped, describeTaskCount(len(tasks)), strings.Join(lines, "\n"))
+}
+
+// Describes a task by its id, which ends its arn, and its task definition, eg "web:42"
+func describeTask(task *ecs.Task) string {
+	return fmt.Sprintf("%s (%s)", utils.RemoveAllRegex(`.*/`, *task.TaskArn), aws.ShortenTaskDefArn(task.TaskDefinitionArn))
+}
+
+// Returns eg "1 task" or "3 tasks"
+func describeTaskCount(count int) string {
+	if count == 1 {
+		return "1 task"
+	}
+	return fmt.Sprintf("%d tasks", count)
+}
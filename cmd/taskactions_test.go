@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"errors"
+	"testing"
+
+	awssdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ecs"
+)
+
+func TestDescribeStoppedTasks(t *testing.T) {
+	task := func(id string) *ecs.Task {
+		return &ecs.Task{
+			TaskArn:           awssdk.String("arn:aws:ecs:us-east-1:123456789012:task/production/" + id),
+			TaskDefinitionArn: awssdk.String("arn:aws:ecs:us-east-1:123456789012:task-definition/web:42"),
+		}
+	}
+
+	tests := []struct {
+		name  string
+		tasks []*ecs.Task
+		errs  []error
+		want  string
+	}{
+		{
+			name:  "one task",
+			tasks: []*ecs.Task{task("a1")},
+			errs:  []error{nil},
+			want:  "Stopped 1 of 1 task:\n\n✔ a1 (web:42)",
+		},
+		{
+			name:  "some tasks failed",
+			tasks: []*ecs.Task{task("a1"), task("b2"), task("c3")},
+			errs:  []error{nil, errors.New("ThrottlingException: Rate exceeded"), nil},
+			want:  "Stopped 2 of 3 tasks:\n\n✔ a1 (web:42)\n✘ b2 (web:42): ThrottlingException: Rate exceeded\n✔ c3 (web:42)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := describeStoppedTasks(tt.tasks, tt.errs); got != tt.want {
+				t.Errorf("describeStoppedTasks() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// Returns a bordered list of items the user can check and uncheck with Enter, with an "Apply" item that calls apply
+// with the checked items in their original order and an "All" item that checks or unchecks every item.
+// Pressing Escape calls cancel. Apply isn't called if nothing is checked.
+func NewChecklist(title string, items []string, checked []string, apply func(checked []string), cancel func()) *tview.List {
+
+	selected := make(map[string]bool)
+	for _, item := range checked {
+		selected[item] = true
+	}
+
+	label := func(item string) string {
+		if selected[item] {
+			return tview.Escape(fmt.Sprintf("[x] %s", item))
+		}
+		return tview.Escape(fmt.Sprintf("[ ] %s", item))
+	}
+
+	list := tview.NewList().
+		ShowSecondaryText(false).
+		SetHighlightFullLine(true)
+	list.
+		SetBorder(true).
+		SetTitle(title).
+		SetBorderColor(tcell.ColorDarkCyan)
+
+	const firstItemIndex = 2
+	list.AddItem("Apply", "", 0, func() {
+		chosen := make([]string, 0)
+		for _, item := range items {
+			if selected[item] {
+				chosen = append(chosen, item)
+			}
+		}
+		if len(chosen) > 0 {
+			apply(chosen)
+		}
+	})
+	list.AddItem("All", "", 0, func() {
+		allSelected := true
+		for _, item := range items {
+			allSelected = allSelected && selected[item]
+		}
+		for i, item := range items {
+			selected[item] = !allSelected
+			list.SetItemText(i+firstItemIndex, label(item), "")
+		}
+	})
+	for i, item := range items {
+		index := i + firstItemIndex
+		item := item
+		list.AddItem(label(item), "", 0, func() {
+			selected[item] = !selected[item]
+			list.SetItemText(index, label(item), "")
+		})
+	}
+
+	list.SetDoneFunc(cancel)
+
+	return list
+}
@@ -0,0 +1,33 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/rivo/tview"
+)
+
+// Returns a scrollable view for a list of labelled values, filled by SetFields
+func NewFieldsView() *tview.TextView {
+	view := tview.NewTextView().
+		SetDynamicColors(true).
+		SetWrap(true).
+		SetWordWrap(true)
+	view.SetBorderPadding(1, 0, 1, 1)
+	return view
+}
+
+// Replaces the view's contents with a line for each label and value pair, lining the values up.
+// A pair with an empty label and value leaves a blank line.
+func SetFields(view *tview.TextView, fields [][2]string) {
+	labelWidth := 0
+	for _, field := range fields {
+		if len(field[0]) > labelWidth {
+			labelWidth = len(field[0])
+		}
+	}
+
+	view.Clear()
+	for _, field := range fields {
+		fmt.Fprintf(view, "[yellow]%-*s[-]  %s\n", labelWidth, field[0], tview.Escape(field[1]))
+	}
+}
@@ -0,0 +1,16 @@
+package ui
+
+import (
+	"github.com/rivo/tview"
+)
+
+// Returns a layout that centers the primitive at the given size, for popups shown over the main view
+func Centered(p tview.Primitive, width, height int) tview.Primitive {
+	return tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(nil, 0, 1, false).
+			AddItem(p, height, 1, true).
+			AddItem(nil, 0, 1, false), width, 1, true).
+		AddItem(nil, 0, 1, false)
+}
@@ -0,0 +1,424 @@
+package ui
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/rivo/tview"
+)
+
+// Marks the header of the column a table is sorted by
+const sortedAscending = "▾"
+const sortedDescending = "▴"
+
+// The header of the row number column, which isn't worth sorting by
+const rowNumberHeader = "#"
+
+// Marks the first cell of a row the user marked
+const markedRow = "✔ "
+
+// Keeps the rows rendered into a table below its header row, so they can be filtered and sorted without rendering them
+// again. Call Update after each render; the filter and sort order are kept and applied to the new rows.
+type TableRows struct {
+	table *tview.Table
+	title string
+
+	// The header texts without sort arrows, and the column the rows are rendered sorted by, if any
+	headers         []string
+	renderedColumn  int
+	renderedAscends bool
+
+	// The column the user sorted the rows by, or -1 to keep them in the order they were rendered
+	sortColumn int
+	ascending  bool
+
+	// Every rendered row's cells, and the text each cell was rendered with
+	rows  [][]*tview.TableCell
+	texts [][]string
+
+	filter  string
+	pattern *regexp.Regexp
+
+	// The ids the first cells of the marked rows reference, eg to act on several tasks at once
+	marked map[string]bool
+}
+
+// Returns the rows of a table with one header row, which has been given its title
+func NewTableRows(table *tview.Table) *TableRows {
+	return &TableRows{table: table, title: table.GetTitle(), sortColumn: -1, marked: make(map[string]bool)}
+}
+
+// Returns the rows of a table with one header row, which the user can sort by clicking a header. The header of the
+// column the rows are rendered sorted by, if any, ends with "▾" for ascending or "▴" for descending order.
+func NewSortableTableRows(table *tview.Table) *TableRows {
+	rows := NewTableRows(table)
+	rows.renderedColumn = -1
+	for column := 0; column < table.GetColumnCount(); column++ {
+		header := table.GetCell(0, column).Text
+		if strings.HasSuffix(header, sortedAscending) || strings.HasSuffix(header, sortedDescending) {
+			rows.renderedColumn = column
+			rows.renderedAscends = strings.HasSuffix(header, sortedAscending)
+			header = strings.TrimRight(strings.TrimSuffix(strings.TrimSuffix(header, sortedAscending), sortedDescending), " ")
+		}
+		rows.headers = append(rows.headers, header)
+
+		column := column
+		table.GetCell(0, column).SetClickedFunc(func() bool {
+			rows.SortBy(column)
+			return true
+		})
+	}
+	return rows
+}
+
+// Records the rows just rendered into the table and applies the filter to them
+func (r *TableRows) Update() {
+	r.rows = make([][]*tview.TableCell, 0, r.table.GetRowCount())
+	r.texts = make([][]string, 0, r.table.GetRowCount())
+	for row := 1; row < r.table.GetRowCount(); row++ {
+		cells := make([]*tview.TableCell, r.table.GetColumnCount())
+		texts := make([]string, r.table.GetColumnCount())
+		for column := range cells {
+			cells[column] = r.table.GetCell(row, column)
+			texts[column] = cells[column].Text
+		}
+		r.rows = append(r.rows, cells)
+		r.texts = append(r.texts, texts)
+	}
+
+	// Forget the marks of rows that are gone, eg stopped tasks
+	rendered := make(map[string]bool)
+	for _, cells := range r.rows {
+		if id, ok := cells[0].GetReference().(string); ok {
+			rendered[id] = true
+		}
+	}
+	for id := range r.marked {
+		if !rendered[id] {
+			delete(r.marked, id)
+		}
+	}
+
+	// The table was rendered again, so keep the selected row number rather than the row it had
+	r.layout(nil)
+}
+
+// Returns the filter, or an empty string if the rows aren't filtered
+func (r *TableRows) Filter() string {
+	return r.filter
+}
+
+// Shows only the rows with a cell matching the filter, which is a case-insensitive regular expression or, if it isn't
+// a valid one, a substring. An empty filter shows every row.
+func (r *TableRows) SetFilter(filter string) {
+	r.filter = filter
+	r.pattern = nil
+	if filter != "" {
+		pattern, err := regexp.Compile("(?i)" + filter)
+		if err != nil {
+			pattern = regexp.MustCompile("(?i)" + regexp.QuoteMeta(filter))
+		}
+		r.pattern = pattern
+	}
+	r.layout(r.selectedCell())
+}
+
+// Sorts the rows by the column in ascending order or, if they're already sorted by it, reverses the order
+func (r *TableRows) SortBy(column int) {
+	sortColumn, ascending := r.sortOrder()
+	if column == sortColumn {
+		r.sort(column, !ascending)
+	} else {
+		r.sort(column, true)
+	}
+}
+
+// Sorts the rows by the next column in ascending order, wrapping around to the first column and skipping the row
+// number column
+func (r *TableRows) SortByNextColumn() {
+	if !r.Sortable() {
+		return
+	}
+	sortColumn, _ := r.sortOrder()
+	r.sort(r.nextColumn(sortColumn), true)
+}
+
+// Reverses the order of the rows
+func (r *TableRows) ReverseSort() {
+	if !r.Sortable() {
+		return
+	}
+	sortColumn, ascending := r.sortOrder()
+	if sortColumn < 0 {
+		sortColumn = r.nextColumn(sortColumn)
+	}
+	r.sort(sortColumn, !ascending)
+}
+
+// Returns the column after the given one, or the first column after -1, skipping the row number column
+func (r *TableRows) nextColumn(column int) int {
+	next := (column + 1) % len(r.headers)
+	if r.headers[next] == rowNumberHeader && len(r.headers) > 1 {
+		next = (next + 1) % len(r.headers)
+	}
+	return next
+}
+
+// Returns true if the rows can be sorted
+func (r *TableRows) Sortable() bool {
+	return len(r.headers) > 0
+}
+
+// Returns the column the rows are sorted by and whether it's in ascending order
+func (r *TableRows) sortOrder() (int, bool) {
+	if r.sortColumn < 0 {
+		return r.renderedColumn, r.renderedAscends
+	}
+	return r.sortColumn, r.ascending
+}
+
+func (r *TableRows) sort(column int, ascending bool) {
+	if !r.Sortable() {
+		return
+	}
+	r.sortColumn = column
+	r.ascending = ascending
+	r.layout(r.selectedCell())
+}
+
+// Marks the selected row or, if it's already marked, unmarks it
+func (r *TableRows) ToggleMark() {
+	cell := r.selectedCell()
+	if cell == nil {
+		return
+	}
+	if id, ok := cell.GetReference().(string); ok {
+		if r.marked[id] {
+			delete(r.marked, id)
+		} else {
+			r.marked[id] = true
+		}
+		r.layout(cell)
+	}
+}
+
+// Returns the ids of the marked rows in the order they're sorted, including those hidden by the filter
+func (r *TableRows) Marked() []string {
+	ids := make([]string, 0, len(r.marked))
+	for _, i := range r.sortedRowIndexes() {
+		if id, ok := r.rows[i][0].GetReference().(string); ok && r.marked[id] {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// Unmarks every row
+func (r *TableRows) ClearMarks() {
+	r.marked = make(map[string]bool)
+	r.layout(r.selectedCell())
+}
+
+// Returns the number of rows rendered, including those hidden by the filter
+func (r *TableRows) Total() int {
+	return len(r.rows)
+}
+
+// Returns the number of rows shown
+func (r *TableRows) Shown() int {
+	return r.table.GetRowCount() - 1
+}
+
+// Puts the rows that match the filter back into the table in sorted order, highlighting the matches and the marked
+// rows, and shows the counts in the title. The row starting with the given cell, if any, stays selected.
+func (r *TableRows) layout(selectedCell *tview.TableCell) {
+	for r.table.GetRowCount() > 1 {
+		r.table.RemoveRow(r.table.GetRowCount() - 1)
+	}
+	r.layoutHeaders()
+
+	shown, selectRow := 0, 0
+	for _, i := range r.sortedRowIndexes() {
+		cells := r.rows[i]
+		if !r.matches(r.texts[i]) {
+			continue
+		}
+		shown++
+		for column, cell := range cells {
+			text := r.highlight(r.texts[i][column])
+			if id, ok := cell.GetReference().(string); ok && column == 0 && r.marked[id] {
+				text = markedRow + text
+			}
+			cell.SetText(text)
+			r.table.SetCell(shown, column, cell)
+		}
+		if cells[0] == selectedCell {
+			selectRow = shown
+		}
+	}
+
+	title := r.title
+	if r.pattern != nil {
+		title = fmt.Sprintf("%s (%d of %d) ", strings.TrimRight(title, " "), shown, len(r.rows))
+	}
+	if len(r.marked) > 0 {
+		title = fmt.Sprintf("%s (%d marked) ", strings.TrimRight(title, " "), len(r.marked))
+	}
+	r.table.SetTitle(title)
+
+	// Keep the selection on a row that's still in the table
+	selectedRow, _ := r.table.GetSelection()
+	if selectRow > 0 && selectRow != selectedRow {
+		r.table.Select(selectRow, 0)
+	} else if selectedRow > shown && shown > 0 {
+		r.table.Select(shown, 0)
+	}
+}
+
+// Shows the sort arrow on the header of the column the rows are sorted by
+func (r *TableRows) layoutHeaders() {
+	sortColumn, ascending := r.sortOrder()
+	for column, header := range r.headers {
+		if column == sortColumn && ascending {
+			header = header + " " + sortedAscending
+		} else if column == sortColumn {
+			header = header + " " + sortedDescending
+		}
+		r.table.GetCell(0, column).SetText(header)
+	}
+}
+
+// Returns the indexes of the rows in the order they're shown
+func (r *TableRows) sortedRowIndexes() []int {
+	indexes := make([]int, len(r.rows))
+	for i := range indexes {
+		indexes[i] = i
+	}
+	if r.sortColumn < 0 {
+		return indexes
+	}
+
+	column := r.sortColumn
+	sort.SliceStable(indexes, func(i, j int) bool {
+		a, b := indexes[i], indexes[j]
+		if !r.ascending {
+			a, b = b, a
+		}
+		return compareCells(r.rows[a][column], r.rows[b][column], r.texts[a][column], r.texts[b][column]) < 0
+	})
+	return indexes
+}
+
+// Returns the first cell of the selected row, or nil if no row is selected
+func (r *TableRows) selectedCell() *tview.TableCell {
+	if selectedRow, _ := r.table.GetSelection(); selectedRow > 0 && selectedRow < r.table.GetRowCount() {
+		return r.table.GetCell(selectedRow, 0)
+	}
+	return nil
+}
+
+func (r *TableRows) matches(texts []string) bool {
+	if r.pattern == nil {
+		return true
+	}
+	for _, text := range texts {
+		if r.pattern.MatchString(text) {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the text with the filter's matches highlighted, escaping the text so its brackets aren't read as color tags
+func (r *TableRows) highlight(text string) string {
+	if r.pattern == nil {
+		return text
+	}
+
+	highlighted := strings.Builder{}
+	last := 0
+	for _, match := range r.pattern.FindAllStringIndex(text, -1) {
+		if match[0] == match[1] {
+			continue
+		}
+		highlighted.WriteString(tview.Escape(text[last:match[0]]))
+		highlighted.WriteString("[black:yellow]")
+		highlighted.WriteString(tview.Escape(text[match[0]:match[1]]))
+		highlighted.WriteString("[-:-]")
+		last = match[1]
+	}
+	highlighted.WriteString(tview.Escape(text[last:]))
+	return highlighted.String()
+}
+
+// Compares two cells by the times or numbers they reference, eg a creation time or a usage ratio, or else by their
+// text with the numbers in it compared by value, so "9" comes before "10". Cells with a referenced value come first.
+func compareCells(a *tview.TableCell, b *tview.TableCell, textA string, textB string) int {
+	valueA, hasValueA := sortValue(a.GetReference())
+	valueB, hasValueB := sortValue(b.GetReference())
+	switch {
+	case hasValueA && hasValueB:
+		if valueA < valueB {
+			return -1
+		} else if valueA > valueB {
+			return 1
+		}
+		return 0
+	case hasValueA:
+		return -1
+	case hasValueB:
+		return 1
+	}
+	return compareNatural(textA, textB)
+}
+
+// Returns the time or number a cell references as a float
+func sortValue(reference interface{}) (float64, bool) {
+	switch value := reference.(type) {
+	case time.Time:
+		return float64(value.UnixNano()), true
+	case float64:
+		return value, true
+	case int64:
+		return float64(value), true
+	case int:
+		return float64(value), true
+	}
+	return 0, false
+}
+
+// Compares two strings ignoring case, with each run of digits compared by its numeric value
+func compareNatural(a string, b string) int {
+	runesA, runesB := []rune(strings.ToLower(a)), []rune(strings.ToLower(b))
+	i, j := 0, 0
+	for i < len(runesA) && j < len(runesB) {
+		if unicode.IsDigit(runesA[i]) && unicode.IsDigit(runesB[j]) {
+			startA, startB := i, j
+			for i < len(runesA) && unicode.IsDigit(runesA[i]) {
+				i++
+			}
+			for j < len(runesB) && unicode.IsDigit(runesB[j]) {
+				j++
+			}
+			numberA := strings.TrimLeft(string(runesA[startA:i]), "0")
+			numberB := strings.TrimLeft(string(runesB[startB:j]), "0")
+			if len(numberA) != len(numberB) {
+				return len(numberA) - len(numberB)
+			}
+			if numberA != numberB {
+				return strings.Compare(numberA, numberB)
+			}
+			continue
+		}
+		if runesA[i] != runesB[j] {
+			return int(runesA[i]) - int(runesB[j])
+		}
+		i++
+		j++
+	}
+	return (len(runesA) - i) - (len(runesB) - j)
+}
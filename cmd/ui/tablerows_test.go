@@ -0,0 +1,266 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/rivo/tview"
+)
+
+// Returns the rows of a table with a header and a row for each of the texts
+func newTestTableRows(texts ...[]string) *TableRows {
+	table := tview.NewTable()
+	table.SetTitle(" Tasks ")
+	for column, header := range []string{"Task", "Status"} {
+		table.SetCell(0, column, tview.NewTableCell(header))
+	}
+	for row, cells := range texts {
+		for column, text := range cells {
+			table.SetCell(row+1, column, tview.NewTableCell(text))
+		}
+	}
+	rows := NewTableRows(table)
+	rows.Update()
+	return rows
+}
+
+func TestTableRowsFilter(t *testing.T) {
+	rows := newTestTableRows(
+		[]string{"web:42", "RUNNING"},
+		[]string{"web-worker:7", "PENDING"},
+		[]string{"api:9", "RUNNING"},
+		[]string{"a.b:1", "STOPPED"},
+	)
+	tests := []struct {
+		name   string
+		filter string
+		want   []string
+	}{
+		{"empty filter shows every row", "", []string{"web:42", "web-worker:7", "api:9", "a.b:1"}},
+		{"substring ignores case", "running", []string{"web:42", "api:9"}},
+		{"matches any cell", "worker", []string{"web-worker:7"}},
+		{"regular expression", "^(api|web):", []string{"web:42", "api:9"}},
+		{"dot is a regular expression wildcard", "a.b", []string{"a.b:1"}},
+		{"invalid regular expression is a substring", "web:(", nil},
+		{"no matches", "DRAINING", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows.SetFilter(tt.filter)
+			if rows.Filter() != tt.filter {
+				t.Errorf("Filter() = %q, want %q", rows.Filter(), tt.filter)
+			}
+			if rows.Shown() != len(tt.want) {
+				t.Fatalf("Shown() = %d, want %d", rows.Shown(), len(tt.want))
+			}
+			for i, text := range tt.want {
+				if got := rows.texts[rowIndex(rows, i+1)][0]; got != text {
+					t.Errorf("row %d = %q, want %q", i+1, got, text)
+				}
+			}
+		})
+	}
+}
+
+// Returns the index in rows.texts of the row shown at the table row
+func rowIndex(rows *TableRows, tableRow int) int {
+	cell := rows.table.GetCell(tableRow, 0)
+	for i, cells := range rows.rows {
+		if cells[0] == cell {
+			return i
+		}
+	}
+	return -1
+}
+
+func TestTableRowsMatches(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter string
+		texts  []string
+		want   bool
+	}{
+		{"no filter", "", []string{"web"}, true},
+		{"matches the first cell", "web", []string{"web", "RUNNING"}, true},
+		{"matches a later cell", "run", []string{"web", "RUNNING"}, true},
+		{"matches no cell", "api", []string{"web", "RUNNING"}, false},
+		{"regular expression anchors apply per cell", "^RUN", []string{"web", "RUNNING"}, true},
+		{"invalid regular expression as a substring", "1+(", []string{"1+(2)"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows := newTestTableRows()
+			rows.SetFilter(tt.filter)
+			if got := rows.matches(tt.texts); got != tt.want {
+				t.Errorf("matches(%q) = %v, want %v", tt.texts, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTableRowsHighlight(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter string
+		text   string
+		want   string
+	}{
+		{"no filter", "", "web:42", "web:42"},
+		{"no match", "api", "web:42", "web:42"},
+		{"match in the middle", "b:4", "web:42", "we[black:yellow]b:4[-:-]2"},
+		{"keeps the text's case", "WEB", "web:42", "[black:yellow]web[-:-]:42"},
+		{"every match", "web", "web-web", "[black:yellow]web[-:-]-[black:yellow]web[-:-]"},
+		{"regular expression", `\d+`, "web:42", "web:[black:yellow]42[-:-]"},
+		{"empty matches are skipped", "x*", "web", "web"},
+		{"invalid regular expression as a substring", "(", "f(x)", "f[black:yellow]([-:-]x)"},
+		{"escapes brackets around a match", "web", "[web] x[y]", "[[black:yellow]web[-:-]] x[y[]"},
+		{"escapes brackets in a match", `x\[y\]`, "x[y]", "[black:yellow]x[y[][-:-]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows := newTestTableRows()
+			rows.SetFilter(tt.filter)
+			if got := rows.highlight(tt.text); got != tt.want {
+				t.Errorf("highlight(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTableRowsFilterTitle(t *testing.T) {
+	rows := newTestTableRows([]string{"web:42", "RUNNING"}, []string{"api:9", "RUNNING"})
+
+	rows.SetFilter("web")
+	if got, want := rows.table.GetTitle(), " Tasks (1 of 2) "; got != want {
+		t.Errorf("title = %q, want %q", got, want)
+	}
+	if got, want := rows.table.GetCell(1, 0).Text, "[black:yellow]web[-:-]:42"; got != want {
+		t.Errorf("shown cell = %q, want %q", got, want)
+	}
+
+	rows.SetFilter("")
+	if got, want := rows.table.GetTitle(), " Tasks "; got != want {
+		t.Errorf("title = %q, want %q", got, want)
+	}
+}
+
+func TestCompareNatural(t *testing.T) {
+	tests := []struct {
+		a    string
+		b    string
+		want int
+	}{
+		{"9", "10", -1},
+		{"10", "9", 1},
+		{"web:9", "web:10", -1},
+		{"web:010", "web:9", 1},
+		{"web:07", "web:7", 0},
+		{"Web", "web", 0},
+		{"api", "web", -1},
+		{"web", "web-worker", -1},
+		{"1.5 GiB", "12 GiB", -1},
+		{"", "a", -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.a+" vs "+tt.b, func(t *testing.T) {
+			if got := sign(compareNatural(tt.a, tt.b)); got != tt.want {
+				t.Errorf("compareNatural(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+// Returns -1, 0 or 1 for a comparison result
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+	return 0
+}
+
+func TestTableRowsSortBy(t *testing.T) {
+	table := tview.NewTable()
+	for column, header := range []string{"Task", "Memory"} {
+		table.SetCell(0, column, tview.NewTableCell(header))
+	}
+	for row, cells := range [][]interface{}{{"web:10", 512}, {"web:9", 2048}, {"web:100", 1024}} {
+		table.SetCell(row+1, 0, tview.NewTableCell(cells[0].(string)))
+		table.SetCell(row+1, 1, tview.NewTableCell("").SetReference(cells[1]))
+	}
+	rows := NewSortableTableRows(table)
+	rows.Update()
+
+	tests := []struct {
+		name   string
+		column int
+		want   []string
+		header string
+	}{
+		{"text numbers by value", 0, []string{"web:9", "web:10", "web:100"}, "Task " + sortedAscending},
+		{"again reverses", 0, []string{"web:100", "web:10", "web:9"}, "Task " + sortedDescending},
+		{"referenced numbers", 1, []string{"web:10", "web:100", "web:9"}, "Memory " + sortedAscending},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows.SortBy(tt.column)
+			for i, text := range tt.want {
+				if got := table.GetCell(i+1, 0).Text; got != text {
+					t.Errorf("row %d = %q, want %q", i+1, got, text)
+				}
+			}
+			if got := table.GetCell(0, tt.column).Text; got != tt.header {
+				t.Errorf("header = %q, want %q", got, tt.header)
+			}
+		})
+	}
+}
+
+func TestTableRowsSortByNextColumn(t *testing.T) {
+	newTable := func(headers ...string) *TableRows {
+		table := tview.NewTable()
+		for column, header := range headers {
+			table.SetCell(0, column, tview.NewTableCell(header))
+		}
+		for row, cells := range [][]string{{"1", "web:9", "RUNNING"}, {"2", "api:3", "PENDING"}} {
+			for column, text := range cells {
+				table.SetCell(row+1, column, tview.NewTableCell(text))
+			}
+		}
+		rows := NewSortableTableRows(table)
+		rows.Update()
+		return rows
+	}
+
+	tests := []struct {
+		name    string
+		headers []string
+		presses int
+		want    int
+	}{
+		{"unsorted skips the row number", []string{"#", "Task", "Status"}, 1, 1},
+		{"moves to the next column", []string{"#", "Task", "Status"}, 2, 2},
+		{"wraps around past the row number", []string{"#", "Task", "Status"}, 3, 1},
+		{"moves on from the rendered sort", []string{"#", "Task " + sortedAscending, "Status"}, 1, 2},
+		{"sorts by the first column without a row number", []string{"Id", "Task", "Status"}, 1, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows := newTable(tt.headers...)
+			for i := 0; i < tt.presses; i++ {
+				rows.SortByNextColumn()
+			}
+			if column, ascending := rows.sortOrder(); column != tt.want || !ascending {
+				t.Errorf("sorted by column %d ascending %v, want column %d ascending", column, ascending, tt.want)
+			}
+		})
+	}
+
+	// Reversing unsorted rows sorts them by the first column after the row number
+	rows := newTable("#", "Task", "Status")
+	rows.ReverseSort()
+	if column, _ := rows.sortOrder(); column != 1 {
+		t.Errorf("sorted by column %d, want column 1", column)
+	}
+}
@@ -0,0 +1,92 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// A bordered view that shows one of several tabs at a time, with the tab names in a bar along the top.
+// Left and Right, or a tab's number, switch tabs.
+type TabbedView struct {
+	*tview.Flex
+	tabBar  *tview.TextView
+	tabs    *tview.Pages
+	names   []string
+	current int
+}
+
+// Returns a tabbed view with the given title and no tabs
+func NewTabbedView(title string) *TabbedView {
+	t := &TabbedView{
+		tabBar: tview.NewTextView().
+			SetDynamicColors(true).
+			SetRegions(true).
+			SetWrap(false),
+		tabs: tview.NewPages(),
+	}
+
+	t.Flex = tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(t.tabBar, 1, 0, false).
+		AddItem(t.tabs, 0, 1, true)
+	t.Flex.
+		SetBorder(true).
+		SetTitle(title).
+		SetBorderColor(tcell.ColorGoldenrod)
+
+	t.Flex.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyLeft:
+			t.SelectTab(t.current - 1)
+			return nil
+		case tcell.KeyRight:
+			t.SelectTab(t.current + 1)
+			return nil
+		case tcell.KeyRune:
+			if index, err := strconv.Atoi(string(event.Rune())); err == nil && index >= 1 && index <= len(t.names) {
+				t.SelectTab(index - 1)
+				return nil
+			}
+		}
+		return event
+	})
+
+	return t
+}
+
+// Adds a tab showing the item, selecting it if it's the first tab
+func (t *TabbedView) AddTab(name string, item tview.Primitive) *TabbedView {
+	t.names = append(t.names, name)
+	t.tabs.AddPage(name, item, true, len(t.names) == 1)
+	t.writeTabBar()
+	return t
+}
+
+// Shows the tab at the given index, wrapping around at either end
+func (t *TabbedView) SelectTab(index int) {
+	if len(t.names) == 0 {
+		return
+	}
+	t.current = (index + len(t.names)) % len(t.names)
+	t.tabs.SwitchToPage(t.names[t.current])
+	t.tabBar.Highlight(strconv.Itoa(t.current))
+}
+
+// Returns the index of the tab being shown
+func (t *TabbedView) CurrentTab() int {
+	return t.current
+}
+
+func (t *TabbedView) writeTabBar() {
+	labels := make([]string, 0, len(t.names))
+	for i, name := range t.names {
+		labels = append(labels, fmt.Sprintf(`[white::b]%d[darkcyan::-] ["%d"]%s[""]`, i+1, i, name))
+	}
+	t.tabBar.Clear()
+	fmt.Fprintf(t.tabBar, " %s", strings.Join(labels, "  "))
+	t.tabBar.Highlight(strconv.Itoa(t.current))
+}
@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"fmt"
 	"time"
 )
 
@@ -24,7 +25,26 @@ func FormatLocalDate(when time.Time) string {
 	return ToLocalTime(when).Format("01/02/06")
 }
 
+// Formats a time with the local time zone as a sortable timestamp, eg "2020-12-03 15:04:05"
+func FormatLocalTimestamp(when time.Time) string {
+	return ToLocalTime(when).Format("2006-01-02 15:04:05")
+}
+
 // Formats a time with the local time zone including time and am/pm
 func FormatLocalTimeAmPmSecs(when time.Time) string {
 	return ToLocalTime(when).Format("3:04:05pm")
 }
+
+// Formats a duration as a short age in its largest whole unit, eg "45s", "12m", "3h", or "2d"
+func FormatAge(age time.Duration) string {
+	switch {
+	case age < time.Minute:
+		return fmt.Sprintf("%ds", int(age.Seconds()))
+	case age < time.Hour:
+		return fmt.Sprintf("%dm", int(age.Minutes()))
+	case age < 24*time.Hour:
+		return fmt.Sprintf("%dh", int(age.Hours()))
+	default:
+		return fmt.Sprintf("%dd", int(age.Hours()/24))
+	}
+}
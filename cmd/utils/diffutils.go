@@ -0,0 +1,59 @@
+package utils
+
+// The kinds of line in a diff
+const (
+	LineSame    = ' '
+	LineRemoved = '-'
+	LineAdded   = '+'
+)
+
+// A line of a diff, which is in both texts, removed from the first, or added by the second
+type DiffLine struct {
+	Kind rune
+	Text string
+}
+
+// Returns a line diff that turns the first text into the second, using their longest common subsequence of lines
+func DiffLines(a []string, b []string) []DiffLine {
+
+	// common[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	common := make([][]int, len(a)+1)
+	for i := range common {
+		common[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				common[i][j] = common[i+1][j+1] + 1
+			} else if common[i+1][j] >= common[i][j+1] {
+				common[i][j] = common[i+1][j]
+			} else {
+				common[i][j] = common[i][j+1]
+			}
+		}
+	}
+
+	diff := make([]DiffLine, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			diff = append(diff, DiffLine{LineSame, a[i]})
+			i++
+			j++
+		case common[i+1][j] >= common[i][j+1]:
+			diff = append(diff, DiffLine{LineRemoved, a[i]})
+			i++
+		default:
+			diff = append(diff, DiffLine{LineAdded, b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		diff = append(diff, DiffLine{LineRemoved, a[i]})
+	}
+	for ; j < len(b); j++ {
+		diff = append(diff, DiffLine{LineAdded, b[j]})
+	}
+	return diff
+}
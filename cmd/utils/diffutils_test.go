@@ -0,0 +1,38 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+// Returns the diff as one string, with each line prefixed by its kind, eg " a -b +c"
+func formatDiff(diff []DiffLine) string {
+	lines := make([]string, 0, len(diff))
+	for _, line := range diff {
+		lines = append(lines, string(line.Kind)+line.Text)
+	}
+	return strings.Join(lines, " ")
+}
+
+func TestDiffLines(t *testing.T) {
+	tests := []struct {
+		name string
+		a    []string
+		b    []string
+		want string
+	}{
+		{"both empty", nil, nil, ""},
+		{"first empty", nil, []string{"a", "b"}, "+a +b"},
+		{"second empty", []string{"a", "b"}, []string{}, "-a -b"},
+		{"identical", []string{"a", "b", "c"}, []string{"a", "b", "c"}, " a  b  c"},
+		{"pure insertion", []string{"a", "c"}, []string{"a", "b", "c", "d"}, " a +b  c +d"},
+		{"pure deletion", []string{"a", "b", "c", "d"}, []string{"b", "d"}, "-a  b -c  d"},
+		{"interleaved changes", []string{"a", "b", "c", "d"}, []string{"a", "x", "c", "e"}, " a -b +x  c -d +e"},
+		{"moved line", []string{"a", "b", "c"}, []string{"c", "a", "b"}, "+c  a  b -c"},
+	}
+	for _, test := range tests {
+		if got := formatDiff(DiffLines(test.a, test.b)); got != test.want {
+			t.Errorf("%s: DiffLines(%v, %v) = %q, want %q", test.name, test.a, test.b, got, test.want)
+		}
+	}
+}
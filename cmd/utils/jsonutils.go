@@ -0,0 +1,77 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Formats a value as indented JSON with sorted keys, leaving out null fields, eg the unset fields of an AWS SDK struct
+func PrettyJSON(v interface{}) (string, error) {
+	generic, err := toGenericJSON(v)
+	if err != nil {
+		return "", err
+	}
+
+	pretty, err := json.MarshalIndent(generic, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(pretty), nil
+}
+
+// Formats a value as YAML with the same fields as PrettyJSON, eg the AWS SDK field names
+func PrettyYAML(v interface{}) (string, error) {
+	generic, err := toGenericJSON(v)
+	if err != nil {
+		return "", err
+	}
+
+	pretty, err := yaml.Marshal(generic)
+	if err != nil {
+		return "", err
+	}
+	return string(pretty), nil
+}
+
+// Returns the value as it's read back from JSON, as maps, slices and scalars, without its null fields. Whole numbers
+// are read as integers, so they aren't formatted as floats, eg 1.6e+09.
+func toGenericJSON(v interface{}) (interface{}, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var generic interface{}
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	if err := decoder.Decode(&generic); err != nil {
+		return nil, err
+	}
+	return removeNulls(generic), nil
+}
+
+func removeNulls(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		for key, field := range value {
+			if field == nil {
+				delete(value, key)
+			} else {
+				value[key] = removeNulls(field)
+			}
+		}
+	case []interface{}:
+		for i, item := range value {
+			value[i] = removeNulls(item)
+		}
+	case json.Number:
+		if i, err := value.Int64(); err == nil {
+			return i
+		}
+		f, _ := value.Float64()
+		return f
+	}
+	return v
+}
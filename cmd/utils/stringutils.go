@@ -29,27 +29,27 @@ func RemoveAllRegex(pattern string, src string) string {
 
 // Returns the right x chars from a string
 func TakeRight(s string, max int) string {
-	result := s
+	result := []rune(s)
 	if len(result) > max {
 		if max > 1 {
 			max -= 1
 		}
 		start := len(result) - max - 1
-		result = "…" + s[start:len(result)]
+		result = append([]rune("…"), result[start:]...)
 	}
-	return result
+	return string(result)
 }
 
 // Returns the left x chars from a string
 func TakeLeft(s string, max int) string {
-	result := s
+	result := []rune(s)
 	if len(result) > max {
 		if max > 1 {
 			max -= 1
 		}
-		result = s[0:max] + "…"
+		result = append(result[0:max:max], '…')
 	}
-	return result
+	return string(result)
 }
 
 // Builds a one-line meter using the amount and total values limited to the given width
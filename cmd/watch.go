@@ -0,0 +1,219 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/ecs"
+
+	"github.com/swartzrock/ecsview/cmd/aws"
+	"github.com/swartzrock/ecsview/cmd/ecsview"
+	"github.com/swartzrock/ecsview/cmd/pages"
+	"github.com/swartzrock/ecsview/cmd/utils"
+)
+
+// The state of a cluster's services at one poll, for finding what changed since the previous poll
+type servicesSnapshot struct {
+	titles []string
+
+	// Each service's name and row on the services page, keyed by the service arn
+	names map[string]string
+	rows  map[string][]string
+
+	// Each deployment's rollout state, or status if it has none, keyed by the service arn and deployment id
+	deployments map[string]map[string]string
+
+	// Each task's service name and last status, keyed by the task arn, for the tasks started by a service
+	taskServices map[string]string
+	taskStatuses map[string]string
+}
+
+// A change to one service between polls
+type serviceChange struct {
+	service string
+	change  string
+}
+
+// Runs the watch command on the named cluster, eg "watch services production", until it's interrupted
+func runWatch(ctx context.Context, options Options, what string, clusterName string) error {
+	if what != "services" {
+		return fmt.Errorf("unknown watch %q, usage: ecsview watch services <cluster>", what)
+	}
+	if options.Output != "table" {
+		return fmt.Errorf("watch only prints tables, not %s", options.Output)
+	}
+	if options.Interval <= 0 {
+		return fmt.Errorf("the interval must be more than zero, not %s", options.Interval)
+	}
+
+	cluster, err := findCluster(ctx, clusterName)
+	if err != nil {
+		return err
+	}
+
+	// Stop watching on Ctrl-C or a kill rather than exiting mid-line
+	ctx, cancel := withInterrupt(ctx)
+	defer cancel()
+
+	return watchServices(ctx, os.Stdout, cluster, options.Interval)
+}
+
+// Prints the cluster's services, then reloads them every interval and prints what changed, until the context is done.
+// Each change is printed on its own line with the time it was seen, eg
+// "2020-12-03 15:04:05  web  task …d05c6a4e Pending → Running".
+func watchServices(ctx context.Context, w io.Writer, cluster *aws.EcsCluster, interval time.Duration) error {
+	fmt.Fprintf(w, "%s  watching the services in %s every %s\n", utils.FormatLocalTimestamp(time.Now()), *cluster.ClusterName, interval)
+
+	var previous *servicesSnapshot
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		ecsData, err := ecsview.RefreshClusterData(ctx, cluster, nil)
+		if ctx.Err() != nil {
+			return nil
+		}
+		now := utils.FormatLocalTimestamp(time.Now())
+
+		// Skip a poll that partly failed rather than report what failed to load as removed, and what did load next
+		// time as new
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s  unable to load %s: %v\n", now, *cluster.ClusterName, err)
+		} else {
+			snapshot := newServicesSnapshot(ecsData)
+			if previous == nil {
+				titles, rows := pages.PageText("services", userConfig, ecsData)
+				if err := printOutput(w, "table", titles, rows, nil); err != nil {
+					return err
+				}
+			} else {
+				for _, c := range diffServicesSnapshots(previous, snapshot) {
+					fmt.Fprintf(w, "%s  %s  %s\n", now, c.service, c.change)
+				}
+			}
+			previous = snapshot
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Returns the state of the services in the cluster data
+func newServicesSnapshot(ecsData *ecsview.ClusterData) *servicesSnapshot {
+	titles, rows := pages.PageText("services", userConfig, ecsData)
+	snapshot := &servicesSnapshot{
+		titles:       titles,
+		names:        make(map[string]string),
+		rows:         make(map[string][]string),
+		deployments:  make(map[string]map[string]string),
+		taskServices: make(map[string]string),
+		taskStatuses: make(map[string]string),
+	}
+
+	for i, service := range ecsData.Services {
+		snapshot.names[*service.ServiceArn] = *service.ServiceName
+		snapshot.rows[*service.ServiceArn] = rows[i]
+		snapshot.deployments[*service.ServiceArn] = make(map[string]string)
+		for _, deployment := range service.Deployments {
+			state := *deployment.Status
+			if deployment.RolloutState != nil {
+				state = *deployment.RolloutState
+			}
+			snapshot.deployments[*service.ServiceArn][*deployment.Id] = state
+		}
+	}
+
+	// Tasks started by a service are in the service's group, eg "service:web"
+	for _, tasks := range [][]*ecs.Task{ecsData.Tasks, ecsData.StoppedTasks} {
+		for _, task := range tasks {
+			if task.Group != nil && strings.HasPrefix(*task.Group, "service:") {
+				snapshot.taskServices[*task.TaskArn] = strings.TrimPrefix(*task.Group, "service:")
+				snapshot.taskStatuses[*task.TaskArn] = *task.LastStatus
+			}
+		}
+	}
+	return snapshot
+}
+
+// Returns the changes from the previous to the next snapshot: services added and removed, changed columns on the
+// services page, deployments started and their rollout state changes, and tasks started and their status transitions
+func diffServicesSnapshots(previous *servicesSnapshot, next *servicesSnapshot) []serviceChange {
+	changes := make([]serviceChange, 0)
+
+	for _, arn := range sortedKeys(previous.names) {
+		if _, found := next.names[arn]; !found {
+			changes = append(changes, serviceChange{previous.names[arn], "service removed"})
+		}
+	}
+
+	for _, arn := range sortedKeys(next.names) {
+		name := next.names[arn]
+		previousRow, found := previous.rows[arn]
+		if !found {
+			changes = append(changes, serviceChange{name, "service added"})
+			continue
+		}
+
+		// Compare the columns if the config is unchanged, which it is unless the page's columns failed to load
+		if len(previous.titles) == len(next.titles) {
+			for i, title := range next.titles {
+				if previousRow[i] != next.rows[arn][i] {
+					changes = append(changes, serviceChange{name, fmt.Sprintf("%s %s → %s", title, previousRow[i], next.rows[arn][i])})
+				}
+			}
+		}
+
+		for _, id := range sortedKeys(next.deployments[arn]) {
+			state := next.deployments[arn][id]
+			previousState, found := previous.deployments[arn][id]
+			if !found {
+				changes = append(changes, serviceChange{name, fmt.Sprintf("deployment %s started, %s", id, formatState(state))})
+			} else if state != previousState {
+				changes = append(changes, serviceChange{name, fmt.Sprintf("deployment %s %s → %s", id, formatState(previousState), formatState(state))})
+			}
+		}
+	}
+
+	for _, arn := range sortedKeys(next.taskStatuses) {
+		status := next.taskStatuses[arn]
+		task := fmt.Sprintf("task %s", utils.TakeRight(utils.RemoveAllRegex(`.*/`, arn), 8))
+		previousStatus, found := previous.taskStatuses[arn]
+		if !found {
+			changes = append(changes, serviceChange{next.taskServices[arn], fmt.Sprintf("%s started, %s", task, formatState(status))})
+		} else if status != previousStatus {
+			changes = append(changes, serviceChange{next.taskServices[arn], fmt.Sprintf("%s %s → %s", task, formatState(previousStatus), formatState(status))})
+		}
+	}
+
+	return changes
+}
+
+// Formats an ECS state for display, eg "In Progress" for "IN_PROGRESS"
+func formatState(state string) string {
+	return utils.LowerTitle(strings.ReplaceAll(state, "_", " "))
+}
+
+// Returns the map's keys in order, so changes are printed in the same order every time
+func sortedKeys(m interface{}) []string {
+	keys := make([]string, 0)
+	switch typed := m.(type) {
+	case map[string]string:
+		for key := range typed {
+			keys = append(keys, key)
+		}
+	case map[string][]string:
+		for key := range typed {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
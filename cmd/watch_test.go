@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+const testWebArn = "arn:aws:ecs:us-east-1:123456789012:service/production/web"
+const testApiArn = "arn:aws:ecs:us-east-1:123456789012:service/production/api"
+const testWebTaskArn = "arn:aws:ecs:us-east-1:123456789012:task/production/0123456789abcdef"
+
+// Returns a snapshot of the web service with a deployment in the rollout state and a task with the status
+func newTestSnapshot(running string, rolloutState string, taskStatus string) *servicesSnapshot {
+	return &servicesSnapshot{
+		titles:       []string{"Name", "Running"},
+		names:        map[string]string{testWebArn: "web"},
+		rows:         map[string][]string{testWebArn: {"web", running}},
+		deployments:  map[string]map[string]string{testWebArn: {"ecs-svc/1": rolloutState}},
+		taskServices: map[string]string{testWebTaskArn: "web"},
+		taskStatuses: map[string]string{testWebTaskArn: taskStatus},
+	}
+}
+
+func TestDiffServicesSnapshots(t *testing.T) {
+	withApi := newTestSnapshot("2", "COMPLETED", "RUNNING")
+	withApi.names[testApiArn] = "api"
+	withApi.rows[testApiArn] = []string{"api", "1"}
+	withApi.deployments[testApiArn] = map[string]string{}
+
+	tests := []struct {
+		name     string
+		previous *servicesSnapshot
+		next     *servicesSnapshot
+		want     []serviceChange
+	}{
+		{
+			name:     "unchanged",
+			previous: newTestSnapshot("2", "COMPLETED", "RUNNING"),
+			next:     newTestSnapshot("2", "COMPLETED", "RUNNING"),
+			want:     []serviceChange{},
+		},
+		{
+			name:     "service added",
+			previous: newTestSnapshot("2", "COMPLETED", "RUNNING"),
+			next:     withApi,
+			want:     []serviceChange{{"api", "service added"}},
+		},
+		{
+			name:     "service removed",
+			previous: withApi,
+			next:     newTestSnapshot("2", "COMPLETED", "RUNNING"),
+			want:     []serviceChange{{"api", "service removed"}},
+		},
+		{
+			name:     "column changed",
+			previous: newTestSnapshot("2", "COMPLETED", "RUNNING"),
+			next:     newTestSnapshot("3", "COMPLETED", "RUNNING"),
+			want:     []serviceChange{{"web", "Running 2 → 3"}},
+		},
+		{
+			name:     "rollout state changed",
+			previous: newTestSnapshot("2", "IN_PROGRESS", "RUNNING"),
+			next:     newTestSnapshot("2", "COMPLETED", "RUNNING"),
+			want:     []serviceChange{{"web", "deployment ecs-svc/1 In Progress → Completed"}},
+		},
+		{
+			name:     "task status changed",
+			previous: newTestSnapshot("2", "COMPLETED", "PENDING"),
+			next:     newTestSnapshot("2", "COMPLETED", "RUNNING"),
+			want:     []serviceChange{{"web", "task …89abcdef Pending → Running"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := diffServicesSnapshots(tt.previous, tt.next); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diffServicesSnapshots() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffServicesSnapshotsStarted(t *testing.T) {
+	previous := newTestSnapshot("2", "COMPLETED", "RUNNING")
+	next := newTestSnapshot("2", "COMPLETED", "RUNNING")
+	next.deployments[testWebArn]["ecs-svc/2"] = "IN_PROGRESS"
+	next.taskServices[testWebTaskArn+"0"] = "web"
+	next.taskStatuses[testWebTaskArn+"0"] = "PROVISIONING"
+
+	want := []serviceChange{
+		{"web", "deployment ecs-svc/2 started, In Progress"},
+		{"web", "task …9abcdef0 started, Provisioning"},
+	}
+	if got := diffServicesSnapshots(previous, next); !reflect.DeepEqual(got, want) {
+		t.Errorf("diffServicesSnapshots() = %q, want %q", got, want)
+	}
+}
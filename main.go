@@ -3,19 +3,92 @@ package main
 import (
 	"flag"
 	"fmt"
+	"os"
+	"strings"
+	"time"
 
 	. "github.com/logrusorgru/aurora"
 
 	"github.com/swartzrock/ecsview/cmd"
+	"github.com/swartzrock/ecsview/cmd/aws"
+	"github.com/swartzrock/ecsview/cmd/config"
+	"github.com/swartzrock/ecsview/cmd/ecsview"
 )
 
+// A flag that can be given more than once, or as a comma-separated list
+type stringListFlag []string
+
+func (s *stringListFlag) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringListFlag) Set(value string) error {
+	*s = append(*s, strings.Split(value, ",")...)
+	return nil
+}
+
 func main() {
+	options := cmd.Options{}
+	flag.StringVar(&options.FixturesFile, "fixtures", "", "display canned ECS data from a JSON `file` instead of querying AWS")
+	flag.StringVar(&options.Session.EndpointURL, "endpoint-url", "",
+		fmt.Sprintf("send AWS requests to this `url`, eg a LocalStack endpoint (env %s)", aws.EndpointURLEnvVar))
+	flag.IntVar(&options.Concurrency, "concurrency", aws.DefaultConcurrency,
+		"the most AWS requests to run at once when loading many clusters or task definitions")
+	flag.DurationVar(&options.CacheTTL, "cache-ttl", ecsview.DefaultCacheTTL,
+		"how long loaded cluster data is shown before it's reloaded, or 0 to keep it until refreshed")
+	flag.DurationVar(&options.RefreshInterval, "refresh-interval", 0,
+		"how often to reload the selected cluster in the background, eg 30s (default off)")
+	flag.Var((*stringListFlag)(&options.Regions), "region",
+		"view clusters in this AWS `region`; repeat or comma-separate for several regions, or use \"all\" for every region")
+	flag.Var((*stringListFlag)(&options.Profiles), "profile",
+		"view clusters with this shared config `profile`; repeat or comma-separate for several profiles")
+	flag.StringVar(&options.Session.RoleArn, "role-arn", "", "assume the IAM role with this `arn` to read your clusters")
+	flag.StringVar(&options.Session.ExternalId, "external-id", "", "the external `id` required to assume the role, if any")
+	flag.StringVar(&options.Session.MfaSerial, "mfa-serial", "",
+		"the serial number or `arn` of the MFA device required to assume the role; ecsview prompts for the token")
+	flag.StringVar(&options.ConfigFile, "config", "",
+		fmt.Sprintf("read settings, eg the columns of each page, from this YAML `file` (default %s)", config.DefaultPath()))
+	flag.StringVar(&options.Output, "o", "table",
+		fmt.Sprintf("print a command's output in this `format`: %s", strings.Join(cmd.OutputFormats, ", ")))
+
+	flag.DurationVar(&options.Interval, "interval", 10*time.Second, "how often the watch and serve commands reload the clusters")
+	flag.StringVar(&options.MetricsAddr, "metrics-addr", ":9102", "the `address` the serve command serves Prometheus metrics on")
+
 	flag.Usage = func() {
 		appName := BrightCyan("ecsview")
-		fmt.Printf("Usage: %s\n\n%s uses your valid AWS session credentials to display a visual inspection of your account's ECS clusters.\n",
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] [command]\n\n%s uses your valid AWS session credentials to display a visual inspection of your account's ECS clusters.\n\n",
 			appName, appName)
+		fmt.Fprintf(flag.CommandLine.Output(), "Commands print to stdout instead of starting the UI:\n")
+		for _, usage := range cmd.CommandUsage {
+			fmt.Fprintf(flag.CommandLine.Output(), "  %-28s%s\n", usage[0], usage[1])
+		}
+		fmt.Fprintf(flag.CommandLine.Output(), "\nFlags:\n")
+		flag.PrintDefaults()
 	}
-	flag.Parse()
+	args := parseFlagsAndArgs(os.Args[1:])
 
-	cmd.Entrypoint()
+	if len(args) > 0 {
+		if err := cmd.RunCommand(options, args); err != nil {
+			fmt.Fprintf(os.Stderr, "ecsview: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	cmd.Entrypoint(options)
+}
+
+// Parses the flags, which can come before, between or after the arguments, eg "services production -o json", and
+// returns the arguments
+func parseFlagsAndArgs(args []string) []string {
+	positional := make([]string, 0)
+	for {
+		// Parse stops at the first argument, so parse the flags after it next
+		_ = flag.CommandLine.Parse(args)
+		args = flag.Args()
+		if len(args) == 0 {
+			return positional
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
 }